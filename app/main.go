@@ -2,79 +2,62 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
-	"reflect"
+
+	"github.com/codecrafters-io/sqlite-starter-go/sqlite"
 )
 
 type SqliteServer struct {
-	header DbHeader
-	reader Reader
+	db *sqlite.DB
 }
 
-func (s SqliteServer) handleDbInfo() {
-	page := s.reader.read(0)
-	page = page[100:]
+func (s SqliteServer) handleDbInfo() error {
+	schema, err := s.db.Schema()
+	if err != nil {
+		return err
+	}
 
-	btreeHeader := parseBtreeHeader(page[:12])
+	fmt.Printf("database page size: %v\n", s.db.PageSize())
+	fmt.Printf("number of tables: %v\n", len(schema))
 
-	fmt.Printf("database page size: %v\n", s.header.dbSizeInBytes)
-	fmt.Printf("number of tables: %v\n", btreeHeader.numberOfCells)
+	return nil
 }
 
-func (s SqliteServer) handleTablesInfo() {
-	schemas := s.reader.getSchemas()
+func (s SqliteServer) handleTablesInfo() error {
+	schema, err := s.db.Schema()
+	if err != nil {
+		return err
+	}
 
-	for i, schema := range schemas {
-		fmt.Printf("%s", schema.tableName)
-		if i < len(schemas)-1 {
+	for i, object := range schema {
+		fmt.Printf("%s", object.TableName)
+		if i < len(schema)-1 {
 			fmt.Printf(" ")
 		}
 	}
-}
-
-func (s SqliteServer) handleSelectStatement(statement SelectStatement) error {
 
-	nodes := []any{}
-
-	for _, val := range statement.fields {
-		nodes = append(nodes, val)
-	}
-
-	planner := CreatePlanner()
-	executionPlan := planner.preparePlan(nodes, statement.from, statement.where)
-
-	extutor := NewExecutor(s.reader)
-	executeCols, err := extutor.execute(executionPlan)
+	return nil
+}
 
+func (s SqliteServer) handleSqlStatement(sqlStatement string) error {
+	rows, err := s.db.Query(sqlStatement)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	showResultSet(nodes, executeCols)
-
-	return nil
-
-}
-
-func (s SqliteServer) handleSqlStatement(sqlStatement string) {
-	parsedSql := parseSqlStatement(sqlStatement)
-
-	switch val := parsedSql.(type) {
-	case SelectStatement:
-		s.handleSelectStatement(val)
-	default:
-		panic(fmt.Sprintf("not defined sqlType: %s", reflect.TypeOf(val)))
-	}
+	return showResultSet(rows)
 }
 
-func (s SqliteServer) handle(command string) {
+func (s SqliteServer) handle(command string) error {
 	switch command {
 	case ".dbinfo":
-		s.handleDbInfo()
+		return s.handleDbInfo()
 	case ".tables":
-		s.handleTablesInfo()
+		return s.handleTablesInfo()
 	default:
-		s.handleSqlStatement(command)
+		return s.handleSqlStatement(command)
 	}
 }
 
@@ -91,13 +74,18 @@ func main() {
 	// command := "select pear, apple, raspberry from banana"
 	// command := "SELECT id, name FROM superheroes WHERE hair_color = 'Violet Hair'"
 
-	reader := NewReader(databaseFilePath)
+	db, err := sqlite.Open(databaseFilePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
 
 	server := SqliteServer{
-		header: parseDatabaseHeader(reader.readHeader()),
-		reader: reader,
+		db: db,
 	}
 
-	server.handle(command)
-
+	err = server.handle(command)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
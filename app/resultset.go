@@ -1,41 +1,66 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/sqlite-starter-go/sqlite"
 )
 
-func showResultSet(nodes []any, executColumns []map[string]ExecuteColumn) {
-	colOrder := []string{}
-	for _, node := range nodes {
-		switch v := node.(type) {
-		case SelectStatementAggregateNode:
-			colOrder = append(colOrder, v.rawName)
-		case SelectStatementFieldNode:
-			colOrder = append(colOrder, v.field)
-		default:
-			panic("not supported")
+func showResultSet(rows *sqlite.Rows) error {
+	for rows.Next() {
+		values := []string{}
+		for _, val := range rows.Values() {
+			values = append(values, formatValue(val))
 		}
+
+		fmt.Println(strings.Join(values, "|"))
 	}
 
-	for _, item := range executColumns {
-		resData := ""
-		for _, col := range colOrder {
-			b := item[col]
-			switch b.colType {
-			case "text":
-				v := b.data.([]uint8)
-				resData += string(v) + "|"
-			case "integer":
-				resData += fmt.Sprint(b.data) + "|"
-			default:
-				panic("unknown type")
-			}
-		}
+	return rows.Err()
+}
 
-		if len(resData) > 0 {
-			resData = resData[:len(resData)-1]
-		}
+// formatValue prints value the same way as sqlite3 shell does, type is taken from value not column declaration
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return formatReal(v)
+	case string:
+		return v
+	case []byte:
+		return "X'" + strings.ToUpper(hex.EncodeToString(v)) + "'"
+	default:
+		return fmt.Sprint(val)
+	}
+}
 
-		fmt.Println(resData)
+// formatReal mimics "%!.15g" format used by sqlite, real values always have decimal point
+func formatReal(val float64) string {
+	if math.IsInf(val, 1) {
+		return "Inf"
+	} else if math.IsInf(val, -1) {
+		return "-Inf"
 	}
+	if val == 0 {
+		// negative zero is printed without sign
+		val = 0
+	}
+
+	formatted := strconv.FormatFloat(val, 'g', 15, 64)
+	if strings.ContainsAny(formatted, ".n") {
+		return formatted
+	}
+
+	if exponent := strings.IndexByte(formatted, 'e'); exponent != -1 {
+		return formatted[:exponent] + ".0" + formatted[exponent:]
+	}
+
+	return formatted + ".0"
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	testCases := []struct {
+		val      any
+		expected string
+	}{
+		{val: nil, expected: ""},
+		{val: int64(-3), expected: "-3"},
+		{val: 1.5, expected: "1.5"},
+		{val: 100.0, expected: "100.0"},
+		{val: 1e20, expected: "1.0e+20"},
+		{val: math.Copysign(0, -1), expected: "0.0"},
+		{val: math.Inf(-1), expected: "-Inf"},
+		{val: []byte("ab"), expected: "X'6162'"},
+	}
+
+	for _, testCase := range testCases {
+		if formatted := formatValue(testCase.val); formatted != testCase.expected {
+			t.Errorf("Expected %v to be formatted as %q, got: %q", testCase.val, testCase.expected, formatted)
+		}
+	}
+}
@@ -1,615 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"strings"
-)
-
-type Tokenizer struct {
-	input string
-	index int
-}
-
-func (t *Tokenizer) eof() bool {
-	return t.index >= len(t.input)
-}
-
-func (t *Tokenizer) peek() byte {
-	if t.eof() {
-		return '$'
-	}
-	return t.input[t.index]
-}
-
-func (t *Tokenizer) next() byte {
-	t.index++
-	return t.peek()
-}
-
-func (t *Tokenizer) skipWhiteSpaces() {
-	for !t.eof() && (t.peek() == ' ' || t.peek() == '\n' || t.peek() == '\t') {
-		t.next()
-	}
-}
-
-type TokenType string
-
-type Token struct {
-	tokenType TokenType
-	value     string
-}
-
-const (
-	spaceToken              TokenType = "SpaceToken"
-	selectToken             TokenType = "SelectToken"
-	tableToken              TokenType = "tableToken"
-	createToken             TokenType = "CreateToken"
-	whereToken              TokenType = "WhereToken"
-	fromToken               TokenType = "FromToken"
-	countToken              TokenType = "CountToken"
-	identifierToken         TokenType = "IdentifierToken"
-	logicalOperatorOrToken  TokenType = "LogicalOperatorOrToken"
-	logicalOperatorAndToken TokenType = "LogicalOperatorAndToken"
-	lParenToken             TokenType = "lParenToken"
-	rParenToken             TokenType = "rParenToken"
-	starToken               TokenType = "starToken"
-	commaToken              TokenType = "commaToken"
-	opToken                 TokenType = "opToken"
-	literalToken            TokenType = "literalToken"
-	eofToken                TokenType = "eofToken"
-)
-
-var clauseKeywords = map[string]Token{
-	"SELECT": Token{tokenType: selectToken},
-	"CREATE": Token{tokenType: createToken},
-	"TABLE":  Token{tokenType: tableToken},
-	"FROM":   Token{tokenType: fromToken},
-	"WHERE":  Token{tokenType: whereToken},
-	"AND":    Token{tokenType: logicalOperatorAndToken},
-	"OR":     Token{tokenType: logicalOperatorOrToken},
-	"COUNT":  Token{tokenType: countToken, value: "count"},
-}
-
-var aggregateTokens = []TokenType{
-	countToken,
-}
-
-func (t TokenType) isAggregate() bool {
-	for _, item := range aggregateTokens {
-		if item == t {
-			return true
-		}
-	}
-	return false
-}
-
-func isAlphaNumerical(char byte) bool {
-	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
-}
-
-// "CREATE TABLE \"superheroes\" (id integer primary key autoincrement, name text not null, eye_color text, hair_color text, appearance_count integer, first_appearance text, first_appearance_year text)"
-// we are missing "\""
-func (t *Tokenizer) parseChars() Token {
-	char := t.peek()
-	stringOutput := ""
-	for isAlphaNumerical(char) || char == '_' {
-		stringOutput += string(char)
-		char = t.next()
-	}
-
-	token := strings.ToUpper(stringOutput)
-
-	if val, ok := clauseKeywords[token]; ok {
-		return val
-	}
-
-	if stringOutput == "" {
-		panic("empty output")
-	}
-
-	return Token{tokenType: identifierToken, value: stringOutput}
-}
-
-func (t *Tokenizer) singleQuoteParse() Token {
-	char := t.next()
-	stringOutput := ""
-	for isAlphaNumerical(char) || char == ' ' {
-		stringOutput += string(char)
-		char = t.next()
-	}
-	if char != '\'' {
-		panic("missing ending '")
-	}
-	t.next()
-
-	return Token{tokenType: literalToken, value: stringOutput}
-}
-
-// for now identical as singleQuote it will change though
-func (t *Tokenizer) doubleQuoteParse() Token {
-	char := t.next()
-	stringOutput := ""
-	for isAlphaNumerical(char) || char == ' ' {
-		stringOutput += string(char)
-		char = t.next()
-	}
-	if char != '"' {
-		panic("missing ending \"")
-	}
-	t.next()
-
-	return Token{tokenType: literalToken, value: stringOutput}
-}
-
-func (t *Tokenizer) tokenizer() []Token {
-	tokens := []Token{}
-	for !t.eof() {
-		switch t.peek() {
-		case '(':
-			tokens = append(tokens, Token{tokenType: lParenToken})
-			t.next()
-			t.skipWhiteSpaces()
-		case ')':
-			tokens = append(tokens, Token{tokenType: rParenToken})
-			t.next()
-		case '*':
-			tokens = append(tokens, Token{tokenType: starToken})
-			t.next()
-		case ',':
-			tokens = append(tokens, Token{tokenType: commaToken})
-			t.next()
-		case '>', '<', '=':
-			tokens = append(tokens, Token{tokenType: opToken, value: string(t.peek())})
-			t.next()
-		case '"':
-			tokens = append(tokens, t.doubleQuoteParse())
-		case '\'':
-			tokens = append(tokens, t.singleQuoteParse())
-		case ' ', '\n', '\t':
-			t.skipWhiteSpaces()
-			if t.peek() == ')' {
-				continue
-			}
-			tokens = append(tokens, Token{tokenType: spaceToken})
-		default:
-			tokens = append(tokens, t.parseChars())
-		}
-	}
-	tokens = append(tokens, Token{tokenType: eofToken})
-	return tokens
-}
-
-// Grammar
-// sqlStatement        -> selectStatement | createStatement
-
-// selectStatement     -> SelectClause FromClause WhereClause
-// SelectClause        -> SELECT Aggregate
-// FromClause          -> FROM tableName
-// WhereClause      -> WHERE Condition | ε
-// Condition        -> Condition AND Condition
-//                   | Condition OR Condition
-//                   | fieldname op compareVal
-
-// createStatement     -> CREATE TABLE identifier createStatementArgs
-// createStatementArgs -> "(" columnList tableConstraintOpt ")" | ε
-// columnList          -> columnDef ColumnListTail
-// ColumnListTail      -> "," columnDef ColumnListTail | ε
-
-// columnDef           -> identifier typeOpt
-// typeOpt             -> type | ε
-// columnConstraintList -> columnConstraint columnConstraintList | ε
-// columnConstraint    -> PRIMARY KEY | NOT NULL | UNIQUE
-
-// Aggregate           -> AggregateType AggregateArg
-// AggregateType       -> COUNT
-// AggregateArg        -> "(" fieldOrStar ")"
-
-// MultiString         -> "*" | string ("," string)*
-
-// fieldOrStar         -> fieldName | starChar
-// fieldName           -> string
-// compareVal          -> string | number
-// tableName           -> string
-// op                  -> "=" | ">" | "<"
-// starChar 		   -> *
-
-type Parser struct {
-	tokens []Token
-	index  int
-}
-
-func (p *Parser) eof() bool {
-	return p.index >= len(p.tokens)
-}
-
-func (p *Parser) peek() Token {
-	return p.tokens[p.index]
-}
-
-func (p *Parser) next() Token {
-	if !p.eof() {
-		p.index++
-	}
-
-	return p.peek()
-}
-
-func (p *Parser) expectNext(t TokenType) (Token, error) {
-	tok := p.next()
-
-	if tok.tokenType != t {
-		return tok, fmt.Errorf("expected token: %v, got: %v", t, tok)
-	}
-
-	return tok, nil
-}
-
-func (p *Parser) skipWhiteSpaces() {
-	if p.peek().tokenType != eofToken && (p.peek().tokenType == spaceToken) {
-		p.next()
-	}
-}
-
-func parseSqlStatement(input string) ASTNode {
-	tokenizer := Tokenizer{
-		input: input,
-	}
-	tokens := tokenizer.tokenizer()
-
-	parser := Parser{
-		tokens: tokens,
-	}
-
-	var astNode ASTNode
-	var err error
-	switch parser.peek().tokenType {
-	case selectToken:
-		astNode, err = parser.selectCause()
-	case createToken:
-		astNode, err = parser.createCause()
-	default:
-		panic("Unknown statement type: " + parser.peek().tokenType)
-	}
-
-	if err != nil {
-		panic(err)
-	}
-
-	return astNode
-
-}
-
-type ASTNode interface{}
-
-type fieldNode struct {
-	name string
-	args []string
-}
-
-type Agregate string
-
-const (
-	countAggregate Agregate = "count"
-)
-
-type SelectStatementFieldNode struct {
-	field string
-}
-type SelectStatementAggregateNode struct {
-	name    Agregate
-	rawName string
-	SelectStatementFieldNode
-}
-
-type SelectStatementNode interface{}
-
-type SelectStatement struct {
-	fields []SelectStatementNode
-	from   string
-	where  []WhereCondition
-}
-
-type WhereCondition struct {
-	field         string
-	operator      string
-	comparisonVal string
-}
-
-type CreateTableStatement struct {
-	tableName string
-	columns   []CreateTableColumn
-}
-
-type Constrain string
-
-const (
-	notNull       Constrain = "NotNull"
-	primaryKey    Constrain = "PrimaryKey"
-	autoIncrement Constrain = "AutoIncrement"
-)
-
-// var sqlConstrainTokens = map[Constrain][][2]string{
-// 	notNullConstrain:    {{"NOT", "NULL"}},
-// 	primaryKeyConstrain: {{"PRIMARY", "KEY"}},
-// 	autoIncrement:       {{"AUTOINCREMENT"}},
-// }
-
-type CreateTableColumn struct {
-	name       string
-	columnType string
-	constrains []Constrain
-}
-
-func (p *Parser) selectCause() (SelectStatement, error) {
-	_, err := p.expectNext(spaceToken)
-	if err != nil {
-		return SelectStatement{}, err
-	}
-
-	fields, err := p.selectStatemntFieldOrAggregate()
-	if err != nil {
-		return SelectStatement{}, err
-	}
-
-	from, err := p.fromClause()
-	if err != nil {
-		return SelectStatement{}, err
-	}
-
-	where, err := p.whereClause()
-	if err != nil {
-		return SelectStatement{}, err
-	}
-
-	return SelectStatement{
-		fields: fields,
-		from:   from,
-		where:  where,
-	}, nil
-}
-
-func (p *Parser) whereClause() ([]WhereCondition, error) {
-	if p.peek().tokenType == eofToken {
-		return nil, nil
-	}
-
-	_, err := p.expectNext(whereToken)
-	if err != nil {
-		return nil, err
-	}
-
-	_, err = p.expectNext(spaceToken)
-	if err != nil {
-		return nil, err
-	}
-
-	whereConditions := []WhereCondition{}
-	p.next()
-
-	for {
-		whereCon, err := p.whereClauseCondition()
-		if err != nil {
-			return nil, err
-		}
-
-		whereConditions = append(whereConditions, whereCon)
-
-		if !(p.peek().tokenType == logicalOperatorAndToken || p.peek().tokenType == logicalOperatorOrToken) {
-			break
-		}
-		p.next()
-		p.skipWhiteSpaces()
-	}
-
-	return whereConditions, nil
-}
-
-func (p *Parser) whereClauseCondition() (WhereCondition, error) {
-	fieldName := p.peek()
-	if fieldName.tokenType != identifierToken {
-		return WhereCondition{}, fmt.Errorf("expected token to be identifierToken got: %v", fieldName.tokenType)
-	}
-	p.next()
-	p.skipWhiteSpaces()
-
-	currentOpToken := p.peek()
-	if currentOpToken.tokenType != opToken {
-		return WhereCondition{}, fmt.Errorf("expected token to be opToken got: %v", currentOpToken.tokenType)
-	}
-	p.next()
-	p.skipWhiteSpaces()
-
-	conditionToken := p.peek()
-	if conditionToken.tokenType != literalToken {
-		return WhereCondition{}, fmt.Errorf("expected token to be literla token got: %v", conditionToken.tokenType)
-	}
-	p.next()
-	p.skipWhiteSpaces()
-
-	return WhereCondition{
-		field:         fieldName.value,
-		operator:      currentOpToken.value,
-		comparisonVal: conditionToken.value,
-	}, nil
-}
-
-func (p *Parser) selectStatemntFieldOrAggregate() ([]SelectStatementNode, error) {
-	// aggregate statement are much complicated than this, we should also check group by statement but at the analyzer phase
-
-	nodes := []SelectStatementNode{}
-	var node SelectStatementNode
-	var err error
-	for {
-		token := p.next()
-
-		switch {
-		case token.tokenType.isAggregate():
-			node, err = p.selectStatementGetAggregateField()
-		case token.tokenType == identifierToken:
-			node = p.selectStatementGetFields()
-		default:
-			return nil, fmt.Errorf("expected select statement to have following field or aggregate instead got: %v", token.tokenType)
-		}
-		if err != nil {
-			return nil, err
-		}
-		nodes = append(nodes, node)
-		p.next()
-
-		if p.peek().tokenType == commaToken {
-			p.next()
-			continue
-		} else if p.peek().tokenType != spaceToken {
-			return nil, fmt.Errorf("expected select statement to space got: %v", token.tokenType)
-		}
-		break
-	}
-
-	return nodes, nil
-}
-
-func (p *Parser) selectStatementGetFields() SelectStatementFieldNode {
-	token := p.peek()
-
-	if token.tokenType == starToken {
-		return SelectStatementFieldNode{field: "*"}
-	}
-
-	return SelectStatementFieldNode{field: token.value}
-}
-
-func (p *Parser) selectStatementGetAggregateField() (SelectStatementAggregateNode, error) {
-	aggregateType := p.peek()
-	_, err := p.expectNext(lParenToken)
-	if err != nil {
-		return SelectStatementAggregateNode{}, err
-	}
-
-	p.next()
-
-	node := p.selectStatementGetFields()
-
-	_, err = p.expectNext(rParenToken)
-	if err != nil {
-		return SelectStatementAggregateNode{}, err
-	}
-
-	return SelectStatementAggregateNode{name: Agregate(aggregateType.value), SelectStatementFieldNode: node, rawName: aggregateType.value + "(" + node.field + ")"}, nil
-
-}
-
-func (p *Parser) createCause() (ASTNode, error) {
-	_, err := p.expectNext(spaceToken)
-	if err != nil {
-		return SelectStatement{}, err
-	}
-
-	token := p.next()
-
-	switch token.tokenType {
-	case tableToken:
-		return p.createTableClause()
-	default:
-		return nil, fmt.Errorf("unsported keyword: %v", string(token.tokenType))
-	}
-
-}
-
-func (p *Parser) createTableClause() (CreateTableStatement, error) {
-	_, err := p.expectNext(spaceToken)
-	if err != nil {
-		return CreateTableStatement{}, err
-	}
-
-	tableName := p.next()
-
-	_, err = p.expectNext(spaceToken)
-	if err != nil {
-		return CreateTableStatement{}, err
-	}
-
-	columns, err := p.readCreateTableColumns()
-
-	if err != nil {
-		return CreateTableStatement{}, err
-	}
-
-	return CreateTableStatement{
-		tableName: tableName.value,
-		columns:   columns,
-	}, nil
-
-}
-
-func (p *Parser) readCreateTableColumns() ([]CreateTableColumn, error) {
-	p.next()
-	if p.peek().tokenType != lParenToken {
-		return nil, fmt.Errorf("expect to start with left parentheses")
-	}
-
-	createTableColumns := []CreateTableColumn{}
-
-	for p.peek().tokenType != rParenToken {
-		p.next()
-		p.skipWhiteSpaces()
-
-		crateTableColumn := CreateTableColumn{constrains: []Constrain{}}
-		crateTableColumn.name = p.peek().value
-
-		p.next()
-
-		crateTableColumn.columnType = p.next().value
-
-		p.next()
-		for p.peek().tokenType != commaToken && p.peek().tokenType != rParenToken {
-			switch strings.ToUpper(p.next().value) {
-			case "NOT":
-				p.next()
-				switch strings.ToUpper(p.next().value) {
-				case "NULL":
-					crateTableColumn.constrains = append(crateTableColumn.constrains, notNull)
-				default:
-					return nil, fmt.Errorf("Not such constrain like not %v", p.peek().value)
-				}
-			case "PRIMARY":
-				p.next()
-				switch strings.ToUpper(p.next().value) {
-				case "KEY":
-					crateTableColumn.constrains = append(crateTableColumn.constrains, primaryKey)
-				default:
-					return nil, fmt.Errorf("Not such constrain like not %v", p.peek().value)
-				}
-			case "AUTOINCREMENT":
-				crateTableColumn.constrains = append(crateTableColumn.constrains, autoIncrement)
-			default:
-				return nil, fmt.Errorf("No such constrain like: %+v", p.peek().value)
-			}
-			p.next()
-
-		}
-		createTableColumns = append(createTableColumns, crateTableColumn)
-
-	}
-
-	return createTableColumns, nil
-}
-
-func (p *Parser) fromClause() (string, error) {
-	token, err := p.expectNext(fromToken)
-	if err != nil {
-		return "", fmt.Errorf("expected from token got: %v", token)
-	}
-
-	token, err = p.expectNext(spaceToken)
-	if err != nil {
-		return "", fmt.Errorf("expected space token got: %v", token)
-	}
-
-	token, err = p.expectNext(identifierToken)
-	if err != nil {
-		return "", fmt.Errorf("expected identifier token got: %v", token)
-	}
-	p.next()
-
-	return token.value, nil
-}
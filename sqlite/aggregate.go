@@ -0,0 +1,563 @@
+package sqlite
+
+import (
+	"bufio"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// default amount of memory used by groups kept in hash table before rows of new groups are spilled to disk
+const defaultAggregateMemoryLimit = 64 << 20
+
+// number of files rows of groups which didn't fit in memory are split into
+const aggregatePartitions = 8
+
+// aggregateIterator returns one aggregated row for every group which passes HAVING condition.
+// Aggregated row is the first table row of the group followed by results of aggregates.
+type aggregateIterator struct {
+	source      rowIterator
+	plan        executionPlan
+	encoding    uint32
+	memoryLimit int
+	groups      *hashAggregator
+}
+
+func (a *aggregateIterator) next() ([]any, bool, error) {
+	if a.groups == nil {
+		err := a.aggregate()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for {
+		row, ok, err := a.groups.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		ok, err = matchWhere(a.plan.having, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return row, true, nil
+		}
+	}
+}
+
+// aggregate reads all source rows, query without GROUP BY has single group even when there are no rows
+func (a *aggregateIterator) aggregate() error {
+	a.groups = newHashAggregator(a.plan, a.encoding, a.memoryLimit)
+	for {
+		row, ok, err := a.source.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		err = a.groups.add(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(a.plan.groupBy) == 0 && len(a.groups.order) == 0 {
+		return a.groups.addGroup("", make([]any, a.plan.width))
+	}
+	return nil
+}
+
+func (a *aggregateIterator) close() error {
+	err := a.source.close()
+	if a.groups != nil {
+		if groupsErr := a.groups.close(); err == nil {
+			err = groupsErr
+		}
+	}
+	return err
+}
+
+type group struct {
+	row         []any
+	aggregators []aggregator
+}
+
+// hashAggregator keeps groups in hash table by values of GROUP BY expressions. When the table reaches
+// memory limit, rows of groups which are not in the table are written to partitions on disk by hash
+// of their group. Groups in memory are returned first, then every partition is aggregated on its own.
+type hashAggregator struct {
+	plan        executionPlan
+	encoding    uint32
+	memoryLimit int
+	seed        maphash.Seed
+	groups      map[string]*group
+	// order holds groups not returned yet in the order they were created
+	order      []*group
+	memoryUsed int
+	partitions []*os.File
+	writers    []*bufio.Writer
+	// partition is aggregator of the partition which groups are being returned
+	partition *hashAggregator
+	// sortedArguments are set for DISTINCT aggregates which argument values come in order
+	sortedArguments []bool
+}
+
+func newHashAggregator(plan executionPlan, encoding uint32, memoryLimit int) *hashAggregator {
+	h := &hashAggregator{
+		plan:        plan,
+		encoding:    encoding,
+		memoryLimit: memoryLimit,
+		seed:        maphash.MakeSeed(),
+		groups:      map[string]*group{},
+	}
+
+	// rows of every group keep order of source rows, spilled rows are read back in the same order
+	for _, call := range plan.aggregates {
+		sorted := false
+		if call.distinct {
+			column, ok := call.args[0].(boundColumnExpr)
+			sorted = ok && isBinaryCollation(column.collation) && isScanOrderedBy(plan.from, column.index)
+		}
+		h.sortedArguments = append(h.sortedArguments, sorted)
+	}
+	return h
+}
+
+// groupKey encodes values of GROUP BY expressions, values equal under their collation have the same key
+func (h *hashAggregator) groupKey(row []any) (string, error) {
+	values := make([]any, len(h.plan.groupBy))
+	for i, expr := range h.plan.groupBy {
+		val, err := evalExpr(expr, row)
+		if err != nil {
+			return "", err
+		}
+		collation, _ := exprCollation(expr)
+		values[i] = equalityKey(val, collation)
+	}
+
+	return string(encodeRecord(values)), nil
+}
+
+func (h *hashAggregator) add(row []any) error {
+	key, err := h.groupKey(row)
+	if err != nil {
+		return err
+	}
+
+	g, ok := h.groups[key]
+	if !ok {
+		// at least one group is aggregated in memory, so every pass over spilled rows makes progress
+		if len(h.groups) > 0 && h.memoryUsed >= h.memoryLimit {
+			return h.spill(key, row)
+		}
+
+		err = h.addGroup(key, row)
+		if err != nil {
+			return err
+		}
+		g = h.groups[key]
+	}
+
+	for i, aggregator := range g.aggregators {
+		args, err := project(h.plan.aggregates[i].args, row)
+		if err != nil {
+			return err
+		}
+		err = aggregator.step(args)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *hashAggregator) addGroup(key string, row []any) error {
+	g := &group{row: row}
+	for i, call := range h.plan.aggregates {
+		aggregator, err := newAggregator(call, h.encoding)
+		if err != nil {
+			return err
+		}
+		if distinct, ok := aggregator.(*distinctAggregator); ok {
+			distinct.sorted = h.sortedArguments[i]
+		}
+		g.aggregators = append(g.aggregators, aggregator)
+	}
+
+	h.groups[key] = g
+	h.order = append(h.order, g)
+	h.memoryUsed += rowSize(row) + len(key)
+	return nil
+}
+
+// spill writes row to the partition of its group, all rows of one group end up in the same partition
+func (h *hashAggregator) spill(key string, row []any) error {
+	if h.partitions == nil {
+		for range aggregatePartitions {
+			file, err := os.CreateTemp("", "sqlite-group-*")
+			if err != nil {
+				return err
+			}
+			h.partitions = append(h.partitions, file)
+			h.writers = append(h.writers, bufio.NewWriter(file))
+		}
+	}
+
+	partition := maphash.String(h.seed, key) % aggregatePartitions
+	return writeRow(h.writers[partition], row)
+}
+
+// next returns aggregated row of the following group, false is returned when all groups were returned
+func (h *hashAggregator) next() ([]any, bool, error) {
+	for {
+		if len(h.order) > 0 {
+			g := h.order[0]
+			h.order = h.order[1:]
+
+			row := g.row
+			for _, aggregator := range g.aggregators {
+				result, err := aggregator.result()
+				if err != nil {
+					return nil, false, err
+				}
+				row = append(row, result)
+			}
+			return row, true, nil
+		}
+
+		if h.partition != nil {
+			row, ok, err := h.partition.next()
+			if err != nil || ok {
+				return row, ok, err
+			}
+
+			err = h.partition.close()
+			if err != nil {
+				return nil, false, err
+			}
+			h.partition = nil
+		}
+
+		if len(h.writers) == 0 {
+			return nil, false, nil
+		}
+
+		err := h.aggregatePartition(h.partitions[len(h.writers)-1], h.writers[len(h.writers)-1])
+		if err != nil {
+			return nil, false, err
+		}
+		h.writers = h.writers[:len(h.writers)-1]
+	}
+}
+
+// aggregatePartition reads spilled rows with new hash seed, so rows are split differently
+// if the partition doesn't fit in memory either
+func (h *hashAggregator) aggregatePartition(file *os.File, writer *bufio.Writer) error {
+	err := writer.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	h.partition = newHashAggregator(h.plan, h.encoding, h.memoryLimit)
+	rows := &runSource{reader: bufio.NewReader(file)}
+	for {
+		row, ok, err := rows.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		err = h.partition.add(row)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// close removes temporary files of this aggregator and of the partition being returned
+func (h *hashAggregator) close() error {
+	var closeErr error
+	if h.partition != nil {
+		closeErr = h.partition.close()
+		h.partition = nil
+	}
+
+	for _, file := range h.partitions {
+		err := file.Close()
+		if err == nil {
+			err = os.Remove(file.Name())
+		}
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	h.partitions = nil
+	h.writers = nil
+
+	return closeErr
+}
+
+// aggregator accumulates values of one aggregate call over all rows of a group
+type aggregator interface {
+	// step adds values of arguments evaluated on one row
+	step(args []any) error
+	result() (any, error)
+}
+
+func newAggregator(call functionCallExpr, encoding uint32) (aggregator, error) {
+	var aggregator aggregator
+	switch call.name {
+	case "count":
+		aggregator = &countAggregator{}
+	case "sum", "total", "avg":
+		aggregator = &sumAggregator{function: call.name}
+	case "min", "max":
+		collation, _ := exprCollation(call.args[0])
+		aggregator = &minMaxAggregator{max: call.name == "max", collation: collation, encoding: encoding}
+	case "group_concat":
+		aggregator = &groupConcatAggregator{}
+	default:
+		return nil, unsupportedError("aggregate function %v", call.name)
+	}
+
+	if call.distinct {
+		collation, _ := exprCollation(call.args[0])
+		aggregator = &distinctAggregator{aggregator: aggregator, collation: collation, seen: map[string]bool{}}
+	}
+	return aggregator, nil
+}
+
+// distinctAggregator passes to the aggregator only values which were not seen yet,
+// values equal under collation of the argument are the same value. When values come
+// in order only the previous value is kept instead of all of them.
+type distinctAggregator struct {
+	aggregator
+	collation string
+	seen      map[string]bool
+	sorted    bool
+	previous  *string
+}
+
+func (a *distinctAggregator) step(args []any) error {
+	key := rowKey(args[:1], []string{a.collation})
+	if a.sorted {
+		if a.previous != nil && *a.previous == key {
+			return nil
+		}
+		a.previous = &key
+		return a.aggregator.step(args)
+	}
+
+	if a.seen[key] {
+		return nil
+	}
+	a.seen[key] = true
+	return a.aggregator.step(args)
+}
+
+// countAggregator counts rows, with argument only rows where the argument is not null are counted
+type countAggregator struct {
+	count int64
+}
+
+func (a *countAggregator) step(args []any) error {
+	if len(args) == 0 || args[0] != nil {
+		a.count++
+	}
+	return nil
+}
+
+func (a *countAggregator) result() (any, error) {
+	return a.count, nil
+}
+
+// sumAggregator adds values for sum, total and avg. Integers are added exactly until real or text which
+// isn't integer is seen, then values are added as reals with Kahan-Babuska-Neumaier compensation, the same
+// as sqlite does. Sum fails when integers overflow before any real is seen, total and avg never fail.
+type sumAggregator struct {
+	function string
+	count    int64
+	intSum   int64
+	// realSum and realErr are used once approx is set
+	realSum  float64
+	realErr  float64
+	approx   bool
+	overflow bool
+}
+
+func (a *sumAggregator) step(args []any) error {
+	val := args[0]
+	if text, ok := val.(string); ok {
+		if number, ok := textToNumber(text); ok {
+			val = number
+		}
+	}
+	if val == nil {
+		return nil
+	}
+	a.count++
+
+	intVal, isInt := val.(int64)
+	switch {
+	case isInt && !a.approx:
+		sum, ok := addInt64(a.intSum, intVal)
+		if ok {
+			a.intSum = sum
+			return nil
+		}
+		a.overflow = true
+		a.startApprox()
+		a.addInt(intVal)
+	case isInt:
+		a.addInt(intVal)
+	default:
+		if !a.approx {
+			a.startApprox()
+		}
+		a.addReal(toFloat64(toNumeric(val)))
+	}
+	return nil
+}
+
+// addInt64 returns false when the sum doesn't fit in int64
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+	return sum, true
+}
+
+// integers with absolute value from 2^52 can't be converted to real exactly, they are split into two parts
+const maxExactInteger = 1 << 52
+
+func (a *sumAggregator) startApprox() {
+	a.approx = true
+	a.realSum, a.realErr = float64(a.intSum), 0
+	if a.intSum <= -maxExactInteger || a.intSum >= maxExactInteger {
+		small := a.intSum % 16384
+		a.realSum, a.realErr = float64(a.intSum-small), float64(small)
+	}
+}
+
+func (a *sumAggregator) addInt(val int64) {
+	if val <= -maxExactInteger || val >= maxExactInteger {
+		small := val % 16384
+		a.addReal(float64(val - small))
+		a.addReal(float64(small))
+		return
+	}
+	a.addReal(float64(val))
+}
+
+func (a *sumAggregator) addReal(val float64) {
+	sum := a.realSum + val
+	if math.Abs(a.realSum) > math.Abs(val) {
+		a.realErr += (a.realSum - sum) + val
+	} else {
+		a.realErr += (val - sum) + a.realSum
+	}
+	a.realSum = sum
+}
+
+// realResult returns compensated sum, error term is ignored when the sum overflowed
+func (a *sumAggregator) realResult() float64 {
+	if !a.approx {
+		return float64(a.intSum)
+	}
+	if math.IsInf(a.realErr, 0) || math.IsNaN(a.realErr) {
+		return a.realSum
+	}
+	return a.realSum + a.realErr
+}
+
+func (a *sumAggregator) result() (any, error) {
+	switch {
+	case a.function == "total":
+		return a.realResult(), nil
+	case a.count == 0:
+		return nil, nil
+	case a.function == "avg":
+		return a.realResult() / float64(a.count), nil
+	case a.overflow:
+		return nil, fmt.Errorf("integer overflow")
+	case a.approx:
+		return a.realResult(), nil
+	default:
+		return a.intSum, nil
+	}
+}
+
+// minMaxAggregator keeps the smallest or the biggest value which isn't null, the first one wins among equal values
+type minMaxAggregator struct {
+	max       bool
+	collation string
+	encoding  uint32
+	value     any
+}
+
+func (a *minMaxAggregator) step(args []any) error {
+	val := args[0]
+	if val == nil {
+		return nil
+	}
+
+	if a.value == nil {
+		a.value = val
+		return nil
+	}
+
+	cmp := compareCollated(val, a.value, a.collation, a.encoding)
+	if (a.max && cmp > 0) || (!a.max && cmp < 0) {
+		a.value = val
+	}
+	return nil
+}
+
+func (a *minMaxAggregator) result() (any, error) {
+	return a.value, nil
+}
+
+// groupConcatAggregator joins values which are not null, separator of every row except the first one
+// goes before its value
+type groupConcatAggregator struct {
+	builder strings.Builder
+	values  int
+}
+
+func (a *groupConcatAggregator) step(args []any) error {
+	if args[0] == nil {
+		return nil
+	}
+
+	if a.values > 0 {
+		separator := ","
+		if len(args) > 1 {
+			separator = valueToText(args[1])
+		}
+		a.builder.WriteString(separator)
+	}
+	a.builder.WriteString(valueToText(args[0]))
+	a.values++
+	return nil
+}
+
+func (a *groupConcatAggregator) result() (any, error) {
+	if a.values == 0 {
+		return nil, nil
+	}
+	return a.builder.String(), nil
+}
@@ -0,0 +1,145 @@
+package sqlite
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHashAggregatorSpillsGroupsToPartitions(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	plan := executionPlan{
+		width:      2,
+		groupBy:    []expression{boundColumnExpr{index: 0, name: "a"}},
+		aggregates: []functionCallExpr{{name: "count", star: true}},
+	}
+
+	// integral real belongs to the same group as integer
+	h := newHashAggregator(plan, utf8Encoding, 256)
+	for i := int64(0); i < 1000; i++ {
+		var val any = i % 100
+		if i%2 == 0 {
+			val = float64(i % 100)
+		}
+		err := h.add([]any{val, i})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(h.partitions) == 0 {
+		t.Errorf("expected groups to be spilled to partitions")
+	}
+
+	counts := map[int64]int64{}
+	for {
+		row, ok, err := h.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+
+		key := int64(toFloat64(row[0]))
+		if _, ok := counts[key]; ok {
+			t.Errorf("expected group %v to be returned once", key)
+		}
+		counts[key] = row[2].(int64)
+	}
+
+	if len(counts) != 100 {
+		t.Errorf("expected 100 groups, got: %v", len(counts))
+	}
+	for key, count := range counts {
+		if count != 10 {
+			t.Errorf("expected group %v to have 10 rows, got: %v", key, count)
+		}
+	}
+
+	err := h.close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(tempDir, "*"))
+	if len(files) != 0 {
+		t.Errorf("expected temporary files to be removed, got: %v", files)
+	}
+}
+
+func aggregate(t *testing.T, call functionCallExpr, values ...[]any) (any, error) {
+	t.Helper()
+
+	aggregator, err := newAggregator(call, utf8Encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range values {
+		err = aggregator.step(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return aggregator.result()
+}
+
+func TestSumAggregators(t *testing.T) {
+	arg := []expression{boundColumnExpr{index: 0}}
+	sum := functionCallExpr{name: "sum", args: arg}
+	total := functionCallExpr{name: "total", args: arg}
+	avg := functionCallExpr{name: "avg", args: arg}
+
+	testCases := []struct {
+		call     functionCallExpr
+		values   [][]any
+		expected any
+		fails    bool
+	}{
+		{call: sum, values: [][]any{{int64(1)}, {nil}, {"2"}}, expected: int64(3)},
+		{call: sum, values: [][]any{{int64(1)}, {"2.5"}}, expected: 3.5},
+		{call: sum, values: [][]any{{int64(1)}, {"abc"}}, expected: 1.0},
+		{call: sum, values: [][]any{{nil}}, expected: nil},
+		{call: sum, values: [][]any{{int64(math.MaxInt64)}, {int64(1)}}, fails: true},
+		{call: sum, values: [][]any{{int64(math.MaxInt64)}, {int64(1)}, {1.5}}, fails: true},
+		{call: sum, values: [][]any{{1.5}, {int64(math.MaxInt64)}, {int64(1)}}, expected: 9223372036854775808.0 + 1.5},
+		{call: sum, values: [][]any{{0.1}, {0.2}, {0.3}}, expected: 0.6},
+		{call: total, values: [][]any{{int64(math.MaxInt64)}, {int64(1)}}, expected: 9223372036854775808.0},
+		{call: total, values: [][]any{}, expected: 0.0},
+		{call: avg, values: [][]any{{int64(1)}, {int64(2)}, {nil}}, expected: 1.5},
+		{call: avg, values: [][]any{{nil}}, expected: nil},
+	}
+
+	for _, testCase := range testCases {
+		result, err := aggregate(t, testCase.call, testCase.values...)
+		if testCase.fails {
+			if err == nil {
+				t.Errorf("Expected %v of %v to fail, got: %v", testCase.call.name, testCase.values, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("Expected %v of %v to be %v, got: %v", testCase.call.name, testCase.values, testCase.expected, result)
+		}
+	}
+}
+
+func TestGroupConcatAggregator(t *testing.T) {
+	call := functionCallExpr{name: "group_concat", args: []expression{boundColumnExpr{index: 0}, boundColumnExpr{index: 1}}}
+
+	// separator of the first value is not used
+	result, _ := aggregate(t, call, []any{nil, "|"}, []any{"a", "-"}, []any{int64(1), nil}, []any{1.5, "+"})
+	if result != "a1+1.5" {
+		t.Errorf("Expected values to be joined by separator of following value, got: %v", result)
+	}
+
+	result, _ = aggregate(t, call, []any{nil, ","})
+	if result != nil {
+		t.Errorf("Expected no values to give null, got: %v", result)
+	}
+}
@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// storage classes in sqlite sort order, NULL < INTEGER/REAL < TEXT < BLOB
+const (
+	nullClass = iota
+	numericClass
+	textClass
+	blobClass
+)
+
+// storageClass returns class of value which sqlite orders by. Values are always decoded from records
+// or computed by evalExpr so other types are never compared, it would be a bug in the engine.
+func storageClass(val any) int {
+	switch val.(type) {
+	case nil:
+		return nullClass
+	case string:
+		return textClass
+	case int64, float64:
+		return numericClass
+	case []byte:
+		return blobClass
+	default:
+		panic(fmt.Sprintf("unknown value type: %v", reflect.TypeOf(val)))
+	}
+}
+
+// compareValues returns -1, 0 or 1 the same way as sqlite orders records, text is compared
+// by BINARY collation of the database encoding
+func compareValues(a, b any, encoding uint32) int {
+	classA, classB := storageClass(a), storageClass(b)
+	if classA != classB {
+		return cmp.Compare(classA, classB)
+	}
+
+	switch classA {
+	case nullClass:
+		return 0
+	case numericClass:
+		return compareNumbers(a, b)
+	case textClass:
+		return compareText(a.(string), b.(string), encoding)
+	default:
+		return bytes.Compare(a.([]byte), b.([]byte))
+	}
+}
+
+// compareText orders text by its bytes in the encoding. Order of utf-8 bytes is order of code points,
+// utf-16 puts surrogate pairs before code points above U+E000 and utf-16le compares low byte first.
+func compareText(a, b string, encoding uint32) int {
+	if encoding != utf16leEncoding && encoding != utf16beEncoding {
+		return strings.Compare(a, b)
+	}
+
+	// order is decided by the first different code point, text is encoded only for that one
+	for a != "" && b != "" {
+		runeA, sizeA := utf8.DecodeRuneInString(a)
+		runeB, sizeB := utf8.DecodeRuneInString(b)
+		if runeA != runeB {
+			return bytes.Compare(encodeText(string(runeA), encoding), encodeText(string(runeB), encoding))
+		}
+		if a[:sizeA] != b[:sizeB] {
+			// invalid utf-8 sequences are decoded to the same replacement character
+			return strings.Compare(a, b)
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// compareNumbers compares integers exactly, float is used only when one of the values is real
+func compareNumbers(a, b any) int {
+	intA, isIntA := a.(int64)
+	intB, isIntB := b.(int64)
+	if isIntA && isIntB {
+		return cmp.Compare(intA, intB)
+	}
+
+	return cmp.Compare(toFloat64(a), toFloat64(b))
+}
+
+// toFloat64 converts value of numericClass, callers check storageClass first so other values never get here
+func toFloat64(val any) float64 {
+	switch v := val.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		panic(fmt.Sprintf("value is not a number: %v", v))
+	}
+}
+
+// collations by upper cased name, they are used only when both compared values are text.
+// BINARY compares text in database encoding so it is left to compareValues.
+var collations = map[string]func(a, b string) int{
+	"BINARY": nil,
+	"NOCASE": compareNocase,
+	"RTRIM":  compareRtrim,
+}
+
+// compareNocase folds only ASCII letters, the same as sqlite NOCASE collation
+func compareNocase(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		charA, charB := asciiLower(a[i]), asciiLower(b[i])
+		if charA != charB {
+			return cmp.Compare(charA, charB)
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func asciiLower(char byte) byte {
+	if char >= 'A' && char <= 'Z' {
+		return char + 'a' - 'A'
+	}
+	return char
+}
+
+// compareRtrim ignores trailing spaces
+func compareRtrim(a, b string) int {
+	return strings.Compare(strings.TrimRight(a, " "), strings.TrimRight(b, " "))
+}
+
+// compareCollated orders values the same way as compareValues, text values are compared by collation.
+// Empty or unknown collation name means BINARY.
+func compareCollated(a, b any, collation string, encoding uint32) int {
+	textA, okA := a.(string)
+	textB, okB := b.(string)
+	if compare := collations[collation]; compare != nil && okA && okB {
+		return compare(textA, textB)
+	}
+	return compareValues(a, b, encoding)
+}
+
+// equalityKey returns value which is the same for all values equal under the collation,
+// integral reals are turned into integers as they are equal to them
+func equalityKey(val any, collation string) any {
+	switch v := val.(type) {
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v)
+		}
+	case string:
+		switch collation {
+		case "NOCASE":
+			folded := []byte(v)
+			for i, char := range folded {
+				folded[i] = asciiLower(char)
+			}
+			return string(folded)
+		case "RTRIM":
+			return strings.TrimRight(v, " ")
+		}
+	}
+	return val
+}
+
+type affinity string
+
+const (
+	textAffinity    affinity = "TEXT"
+	numericAffinity affinity = "NUMERIC"
+	integerAffinity affinity = "INTEGER"
+	realAffinity    affinity = "REAL"
+	blobAffinity    affinity = "BLOB"
+	// expressions other than column references have no affinity, their values are compared as they are
+	noAffinity affinity = ""
+)
+
+// columnAffinity determines affinity from declared column type using sqlite rules, order of checks matters
+func columnAffinity(columnType string) affinity {
+	columnType = strings.ToUpper(columnType)
+
+	switch {
+	case strings.Contains(columnType, "INT"):
+		return integerAffinity
+	case strings.Contains(columnType, "CHAR"), strings.Contains(columnType, "CLOB"), strings.Contains(columnType, "TEXT"):
+		return textAffinity
+	case strings.Contains(columnType, "BLOB"), columnType == "":
+		return blobAffinity
+	case strings.Contains(columnType, "REAL"), strings.Contains(columnType, "FLOA"), strings.Contains(columnType, "DOUB"):
+		return realAffinity
+	default:
+		return numericAffinity
+	}
+}
+
+func isNumericAffinity(affinity affinity) bool {
+	return affinity == numericAffinity || affinity == integerAffinity || affinity == realAffinity
+}
+
+// comparisonAffinity returns affinity applied to both operands of comparison, numeric affinity wins
+// when both operands have one, otherwise the affinity of the operand which has it is used
+func comparisonAffinity(left, right affinity) affinity {
+	if left != noAffinity && right != noAffinity {
+		if isNumericAffinity(left) || isNumericAffinity(right) {
+			return numericAffinity
+		}
+		return noAffinity
+	}
+
+	if left == noAffinity {
+		return right
+	}
+	return left
+}
+
+// applyAffinity converts value before comparison the same way as sqlite does, numeric affinity converts
+// only text which is well formed number and text affinity converts numbers to text
+func applyAffinity(affinity affinity, val any) any {
+	switch {
+	case isNumericAffinity(affinity):
+		if text, ok := val.(string); ok {
+			if number, ok := textToNumber(text); ok {
+				return number
+			}
+		}
+	case affinity == textAffinity:
+		switch val.(type) {
+		case int64, float64:
+			return valueToText(val)
+		}
+	}
+
+	return val
+}
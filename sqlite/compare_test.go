@@ -0,0 +1,126 @@
+package sqlite
+
+import "testing"
+
+func TestCompareValuesStorageClassOrder(t *testing.T) {
+	ordered := []any{nil, int64(-3), 2.5, int64(3), "3", "abc", []byte{0x00}, []byte("abc")}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		if compareValues(ordered[i], ordered[i+1], utf8Encoding) != -1 {
+			t.Errorf("expected %#v to be smaller than %#v", ordered[i], ordered[i+1])
+		}
+		if compareValues(ordered[i+1], ordered[i], utf8Encoding) != 1 {
+			t.Errorf("expected %#v to be bigger than %#v", ordered[i+1], ordered[i])
+		}
+	}
+
+	if compareValues([]byte("abc"), "abc", utf8Encoding) == 0 {
+		t.Errorf("expected blob to never be equal to text")
+	}
+
+	if compareValues(int64(2), 2.0, utf8Encoding) != 0 {
+		t.Errorf("expected integer and real with the same value to be equal")
+	}
+}
+
+func TestApplyAffinity(t *testing.T) {
+	testCases := []struct {
+		affinity affinity
+		value    any
+		expected any
+	}{
+		{affinity: integerAffinity, value: "12", expected: int64(12)},
+		{affinity: integerAffinity, value: " 12 ", expected: int64(12)},
+		{affinity: integerAffinity, value: "12abc", expected: "12abc"},
+		{affinity: realAffinity, value: "2.5", expected: 2.5},
+		{affinity: numericAffinity, value: "1e3", expected: 1000.0},
+		{affinity: numericAffinity, value: []byte("12"), expected: []byte("12")},
+		{affinity: textAffinity, value: int64(12), expected: "12"},
+		{affinity: textAffinity, value: 2.0, expected: "2.0"},
+		{affinity: blobAffinity, value: "12", expected: "12"},
+		{affinity: noAffinity, value: int64(12), expected: int64(12)},
+	}
+
+	for _, testCase := range testCases {
+		val := applyAffinity(testCase.affinity, testCase.value)
+		if compareValues(val, testCase.expected, utf8Encoding) != 0 || storageClass(val) != storageClass(testCase.expected) {
+			t.Errorf("Expected %v affinity to convert %#v to %#v, got: %#v", testCase.affinity, testCase.value, testCase.expected, val)
+		}
+	}
+}
+
+func TestComparisonAffinity(t *testing.T) {
+	testCases := []struct {
+		left, right affinity
+		expected    affinity
+	}{
+		{left: integerAffinity, right: noAffinity, expected: integerAffinity},
+		{left: noAffinity, right: textAffinity, expected: textAffinity},
+		{left: textAffinity, right: realAffinity, expected: numericAffinity},
+		{left: textAffinity, right: blobAffinity, expected: noAffinity},
+		{left: noAffinity, right: noAffinity, expected: noAffinity},
+	}
+
+	for _, testCase := range testCases {
+		if affinity := comparisonAffinity(testCase.left, testCase.right); affinity != testCase.expected {
+			t.Errorf("Expected %q and %q to be compared with %q affinity, got: %q", testCase.left, testCase.right, testCase.expected, affinity)
+		}
+	}
+}
+
+func TestCompareCollated(t *testing.T) {
+	if compareCollated("abc", "ABC", "NOCASE", utf8Encoding) != 0 {
+		t.Errorf("expected NOCASE to ignore case")
+	}
+	if compareCollated("é", "É", "NOCASE", utf8Encoding) == 0 {
+		t.Errorf("expected NOCASE to fold only ASCII letters")
+	}
+	if compareCollated("abc  ", "abc", "RTRIM", utf8Encoding) != 0 {
+		t.Errorf("expected RTRIM to ignore trailing spaces")
+	}
+	if compareCollated("abc", "ABC", "BINARY", utf8Encoding) <= 0 {
+		t.Errorf("expected BINARY to compare bytes")
+	}
+	if compareCollated(int64(1), "1", "NOCASE", utf8Encoding) >= 0 {
+		t.Errorf("expected collation to be used only for text values")
+	}
+}
+
+func TestEqualityKey(t *testing.T) {
+	testCases := []struct {
+		a, b      any
+		collation string
+		equal     bool
+	}{
+		{a: "Red", b: "rED", collation: "NOCASE", equal: true},
+		{a: "Red", b: "rED", collation: "BINARY", equal: false},
+		{a: "Red  ", b: "Red", collation: "RTRIM", equal: true},
+		{a: int64(2), b: 2.0, collation: "BINARY", equal: true},
+		{a: 2.5, b: int64(2), collation: "BINARY", equal: false},
+		{a: int64(1), b: "1", collation: "NOCASE", equal: false},
+		{a: nil, b: nil, collation: "BINARY", equal: true},
+	}
+
+	for _, testCase := range testCases {
+		keyA, keyB := equalityKey(testCase.a, testCase.collation), equalityKey(testCase.b, testCase.collation)
+		if (keyA == keyB) != testCase.equal {
+			t.Errorf("Expected keys of %v and %v with %v to be equal: %v, got: %v and %v", testCase.a, testCase.b, testCase.collation, testCase.equal, keyA, keyB)
+		}
+	}
+}
+
+func TestCompareTextInDatabaseEncoding(t *testing.T) {
+	// U+FF21 is single code unit in utf-16 while U+1F600 is surrogate pair starting with D8 3D
+	if compareText("\uff21", "😀", utf8Encoding) >= 0 || compareText("\uff21", "😀", utf16beEncoding) <= 0 {
+		t.Errorf("expected surrogate pair to be smaller than U+FF21 only in utf-16")
+	}
+
+	// utf-16le compares the low byte first, U+0142 is stored as 42 01 and U+0061 as 61 00
+	if compareText("ł", "a", utf16beEncoding) <= 0 || compareText("ł", "a", utf16leEncoding) >= 0 {
+		t.Errorf("expected U+0142 to be smaller than U+0061 only in utf-16le")
+	}
+
+	if compareText("ab", "a", utf16leEncoding) <= 0 || compareText("ał", "ał", utf16leEncoding) != 0 {
+		t.Errorf("expected longer text to be bigger than its prefix")
+	}
+}
@@ -0,0 +1,210 @@
+package sqlite
+
+import "fmt"
+
+// compoundPlan combines result rows of selects, operators[i] combines rows of selects[i+1]
+// with rows combined from the selects before it
+type compoundPlan struct {
+	selects   []executionPlan
+	operators []string
+	// collations compare values of each column when rows are checked for equality
+	collations []string
+}
+
+// prepareCompound plans every select on its own, ORDER BY and LIMIT apply to the combined rows
+// so they are planned as query of derived table holding those rows
+func (p planner) prepareCompound(statement selectStatement, outer *columnBinder, scope *cteScope) (executionPlan, []*correlation, error) {
+	first := statement
+	first.with, first.compound, first.orderBy, first.limit, first.offset = nil, nil, nil, nil, nil
+	statements := []selectStatement{first}
+	compound := &compoundPlan{}
+	for _, c := range statement.compound {
+		statements = append(statements, c.statement)
+		compound.operators = append(compound.operators, c.operator)
+	}
+
+	correlations := []*correlation{}
+	for i, core := range statements {
+		plan, coreCorrelations, err := p.prepareSelect(core, outer, scope)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+		if i > 0 && len(plan.columns) != len(compound.selects[0].columns) {
+			return executionPlan{}, nil, fmt.Errorf("SELECTs to the left and right of %v do not have the same number of result columns", compound.operators[i-1])
+		}
+		compound.selects = append(compound.selects, plan)
+		correlations = append(correlations, coreCorrelations...)
+	}
+
+	combined := compound.plan()
+	if len(statement.orderBy) == 0 && statement.limit == nil {
+		return combined, correlations, nil
+	}
+
+	binder := &columnBinder{planner: p, outer: outer, scope: scope, allowAggregates: true}
+	binder.tables = []boundTable{{table: derivedTable("", combined), subquery: &combined, using: map[string]bool{}}}
+	query := selectStatement{
+		columns: []resultColumn{{name: "*", star: true}},
+		orderBy: statement.orderBy,
+		limit:   statement.limit,
+		offset:  statement.offset,
+	}
+	plan, queryCorrelations, err := p.prepareQuery(query, binder, nil)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+
+	for i, key := range plan.orderBy {
+		expr := key.expr
+		if collate, ok := expr.(collateExpr); ok {
+			expr = collate.expr
+		}
+		if _, ok := expr.(boundColumnExpr); !ok {
+			return executionPlan{}, nil, fmt.Errorf("%v ORDER BY term does not match any column in the result set", ordinal(i+1))
+		}
+	}
+
+	return plan, append(correlations, queryCorrelations...), nil
+}
+
+// plan describes combined rows, columns are named by the first select. Values are compared using collation
+// of the leftmost select which column has one, same as sqlite.
+func (c *compoundPlan) plan() executionPlan {
+	first := c.selects[0]
+	plan := executionPlan{columns: first.columns, compound: c, limit: -1}
+	for i, expr := range first.projection {
+		collation := ""
+		for _, selectPlan := range c.selects {
+			collation, _ = exprCollation(selectPlan.projection[i])
+			if collation != "" {
+				break
+			}
+		}
+		if collation == "" {
+			collation = "BINARY"
+		}
+		c.collations = append(c.collations, collation)
+
+		if firstCollation, _ := exprCollation(expr); firstCollation != collation {
+			expr = collateExpr{expr: expr, collation: collation}
+		}
+		plan.projection = append(plan.projection, expr)
+	}
+	return plan
+}
+
+// compoundRows combines rows of the selects, UNION ALL returns rows as they come. The other operators
+// return distinct rows, INTERSECT keeps left rows found in the right select and EXCEPT those which aren't.
+func (e executor) compoundRows(compound *compoundPlan) (rowIterator, error) {
+	rows, err := e.iterator(compound.selects[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operator := range compound.operators {
+		right := compound.selects[i+1]
+		if operator == "UNION ALL" {
+			rows = &concatIterator{executor: e, source: rows, plan: right}
+			continue
+		}
+
+		// distinct rows stay distinct when more rows are added or some are removed, so one set is enough
+		source := rows
+		if distinct, ok := rows.(*distinctIterator); ok {
+			source = distinct.source
+		}
+		if operator == "UNION" {
+			source = &concatIterator{executor: e, source: source, plan: right}
+		} else {
+			source = &setFilterIterator{executor: e, source: source, plan: right, collations: compound.collations, except: operator == "EXCEPT"}
+		}
+		rows = &distinctIterator{source: source, collations: compound.collations}
+	}
+
+	return rows, nil
+}
+
+// concatIterator returns rows of source followed by rows of select, the select is executed
+// once all source rows are read
+type concatIterator struct {
+	executor executor
+	source   rowIterator
+	plan     executionPlan
+	started  bool
+}
+
+func (c *concatIterator) next() ([]any, bool, error) {
+	row, ok, err := c.source.next()
+	if err != nil || ok || c.started {
+		return row, ok, err
+	}
+
+	err = c.source.close()
+	if err != nil {
+		return nil, false, err
+	}
+	c.started = true
+	c.source, err = c.executor.iterator(c.plan)
+	if err != nil {
+		// source is closed already
+		c.source = &memoryIterator{}
+		return nil, false, err
+	}
+	return c.source.next()
+}
+
+func (c *concatIterator) close() error {
+	return c.source.close()
+}
+
+// setFilterIterator returns source rows which are returned by select, or which aren't when except is set.
+// Keys of select rows are read on the first call.
+type setFilterIterator struct {
+	executor   executor
+	source     rowIterator
+	plan       executionPlan
+	collations []string
+	except     bool
+	keys       map[string]bool
+}
+
+func (s *setFilterIterator) next() ([]any, bool, error) {
+	if s.keys == nil {
+		err := s.readKeys()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	for {
+		row, ok, err := s.source.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		if s.keys[rowKey(row, s.collations)] != s.except {
+			return row, true, nil
+		}
+	}
+}
+
+func (s *setFilterIterator) readKeys() error {
+	rows, err := s.executor.iterator(s.plan)
+	if err != nil {
+		return err
+	}
+	defer rows.close()
+
+	s.keys = map[string]bool{}
+	for {
+		row, ok, err := rows.next()
+		if err != nil || !ok {
+			return err
+		}
+		s.keys[rowKey(row, s.collations)] = true
+	}
+}
+
+func (s *setFilterIterator) close() error {
+	s.keys = nil
+	return s.source.close()
+}
@@ -0,0 +1,312 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// default number of rows recursive common table expression can take from its queue
+const defaultRecursionLimit = 1000000
+
+// cteScope holds common table expressions of one WITH clause, names of inner WITH hide the outer ones.
+// Subquery starts its own scope without tables so references from subqueries can be recognized.
+type cteScope struct {
+	// ctes hold tables by lower cased name
+	ctes     map[string]*commonTable
+	parent   *cteScope
+	subquery bool
+}
+
+// commonTable is common table expression, its statement is planned again for every reference
+type commonTable struct {
+	cte   commonTableExpr
+	scope *cteScope
+	// planning is set while the statement is planned, reference to the table is then circular
+	planning bool
+	// working is set while recursive select is planned, its references to the table read working table
+	working      *memoryTable
+	workingTable createTableStatement
+	references   int
+}
+
+// memoryTable holds rows of table which is not stored in database file, rows of working table
+// of recursive common table expression change while the query runs
+type memoryTable struct {
+	rows [][]any
+}
+
+// recursiveTable is planned recursive common table expression, its rows are computed by recursiveIterator
+type recursiveTable struct {
+	name    string
+	initial executionPlan
+	step    executionPlan
+	// distinct is set for UNION, rows equal to already returned ones are then discarded
+	distinct   bool
+	collations []string
+	// working holds the row the step is executed for
+	working *memoryTable
+	// limit is negative when number of rows is not limited
+	limit  int64
+	offset int64
+}
+
+func newCteScope(ctes []commonTableExpr, parent *cteScope) (*cteScope, error) {
+	scope := &cteScope{ctes: map[string]*commonTable{}, parent: parent}
+	for _, cte := range ctes {
+		name := strings.ToLower(cte.name)
+		if _, ok := scope.ctes[name]; ok {
+			return nil, fmt.Errorf("duplicate WITH table name: %v", cte.name)
+		}
+		scope.ctes[name] = &commonTable{cte: cte, scope: scope}
+	}
+	return scope, nil
+}
+
+// lookup finds common table expression by name, nil is returned when there is no such one.
+// Flag tells whether the table is referenced from subquery of the statement which defines it.
+func (s *cteScope) lookup(name string) (*commonTable, bool) {
+	inSubquery := false
+	for ; s != nil; s = s.parent {
+		if table, ok := s.ctes[strings.ToLower(name)]; ok {
+			return table, inSubquery
+		}
+		inSubquery = inSubquery || s.subquery
+	}
+	return nil, false
+}
+
+// bindCommonTable plans reference to common table expression. Statement which ends with UNION or UNION ALL
+// of select referencing the table itself is recursive, the other ones are planned as derived tables.
+func (p planner) bindCommonTable(table *commonTable, alias string, inSubquery bool) (boundTable, error) {
+	name := table.cte.name
+	if alias != "" {
+		name = alias
+	}
+
+	if table.working != nil {
+		// uncorrelated subquery result is cached, it wouldn't see changes of the working table
+		if inSubquery {
+			return boundTable{}, fmt.Errorf("recursive reference in a subquery: %v", table.cte.name)
+		}
+		table.references++
+		if table.references > 1 {
+			return boundTable{}, fmt.Errorf("multiple references to recursive table: %v", table.cte.name)
+		}
+		return boundTable{name: name, table: table.workingTable, memory: table.working, using: map[string]bool{}}, nil
+	}
+	if table.planning {
+		return boundTable{}, fmt.Errorf("circular reference: %v", table.cte.name)
+	}
+
+	table.planning = true
+	defer func() { table.planning = false }()
+
+	recursive, err := p.prepareRecursive(table)
+	if err != nil {
+		return boundTable{}, err
+	}
+	if recursive != nil {
+		definition, err := commonTableDefinition(table.cte, recursive.initial)
+		if err != nil {
+			return boundTable{}, err
+		}
+		return boundTable{name: name, table: definition, recursive: recursive, using: map[string]bool{}}, nil
+	}
+
+	plan, _, err := p.prepareSelect(table.cte.statement, nil, table.scope)
+	if err != nil {
+		return boundTable{}, err
+	}
+	definition, err := commonTableDefinition(table.cte, plan)
+	if err != nil {
+		return boundTable{}, err
+	}
+	return boundTable{name: name, table: definition, subquery: &plan, using: map[string]bool{}}, nil
+}
+
+// prepareRecursive plans statement as initial select followed by recursive select joined by UNION or UNION ALL,
+// nil is returned when the last select doesn't reference the table. ORDER BY and LIMIT of the statement apply
+// to rows of the table.
+func (p planner) prepareRecursive(table *commonTable) (*recursiveTable, error) {
+	statement := table.cte.statement
+	n := len(statement.compound)
+	if n == 0 || !strings.HasPrefix(statement.compound[n-1].operator, "UNION") {
+		return nil, nil
+	}
+	last := statement.compound[n-1]
+
+	initialStatement := statement
+	initialStatement.compound = statement.compound[:n-1]
+	initialStatement.orderBy, initialStatement.limit, initialStatement.offset = nil, nil, nil
+	initial, _, err := p.prepareSelect(initialStatement, nil, table.scope)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := commonTableDefinition(table.cte, initial)
+	if err != nil {
+		return nil, err
+	}
+
+	working := &memoryTable{}
+	table.working, table.workingTable, table.references = working, definition, 0
+	step, _, err := p.prepareSelect(last.statement, nil, table.scope)
+	references := table.references
+	table.working = nil
+	if err != nil || references == 0 {
+		return nil, err
+	}
+
+	if len(step.columns) != len(initial.columns) {
+		return nil, fmt.Errorf("SELECTs to the left and right of %v do not have the same number of result columns", last.operator)
+	}
+	if step.isAggregate() {
+		return nil, fmt.Errorf("recursive aggregate queries not supported")
+	}
+	if len(statement.orderBy) > 0 {
+		return nil, unsupportedError("ORDER BY of recursive common table expression")
+	}
+
+	recursive := &recursiveTable{
+		name:     table.cte.name,
+		initial:  initial,
+		step:     step,
+		distinct: last.operator == "UNION",
+		working:  working,
+	}
+	for i := range definition.columns {
+		recursive.collations = append(recursive.collations, tableColumnCollation(definition, i))
+	}
+
+	recursive.limit, err = p.limitValue(statement.limit, -1)
+	if err != nil {
+		return nil, err
+	}
+	recursive.offset, err = p.limitValue(statement.offset, 0)
+	if err != nil {
+		return nil, err
+	}
+	recursive.offset = max(recursive.offset, 0)
+
+	return recursive, nil
+}
+
+// commonTableDefinition declares table of select result columns, names from column list
+// of common table expression replace names of the columns
+func commonTableDefinition(cte commonTableExpr, plan executionPlan) (createTableStatement, error) {
+	table := derivedTable(cte.name, plan)
+	if len(cte.columns) == 0 {
+		return table, nil
+	}
+
+	if len(cte.columns) != len(table.columns) {
+		return createTableStatement{}, fmt.Errorf("table %v has %v values for %v columns", cte.name, len(table.columns), len(cte.columns))
+	}
+	for i := range table.columns {
+		table.columns[i].name = cte.columns[i]
+	}
+	return table, nil
+}
+
+// derivedRows returns result rows of table which is not read from table btree
+func (e executor) derivedRows(scan tableScan) (rowIterator, error) {
+	switch {
+	case scan.recursive != nil:
+		var rows rowIterator = &recursiveIterator{executor: e, table: scan.recursive}
+		if scan.recursive.limit >= 0 || scan.recursive.offset > 0 {
+			rows = &limitIterator{source: rows, limit: scan.recursive.limit, offset: scan.recursive.offset}
+		}
+		return rows, nil
+	case scan.memory != nil:
+		return &memoryIterator{memorySource{rows: scan.memory.rows}}, nil
+	default:
+		return e.iterator(*scan.subquery)
+	}
+}
+
+type memoryIterator struct {
+	memorySource
+}
+
+func (m *memoryIterator) close() error {
+	return nil
+}
+
+// recursiveIterator returns rows of recursive common table expression. Rows of initial select fill the queue,
+// every row taken from the queue is returned and recursive select is executed with that row as the only row
+// of working table, its rows are added to the queue. Rows are computed only when they are requested,
+// so LIMIT of the query stops recursion which would never end.
+type recursiveIterator struct {
+	executor executor
+	table    *recursiveTable
+	started  bool
+	queue    [][]any
+	// seen holds keys of all rows added to the queue, it is nil for UNION ALL
+	seen       map[string]bool
+	iterations int
+}
+
+func (r *recursiveIterator) next() ([]any, bool, error) {
+	if !r.started {
+		r.started = true
+		if r.table.distinct {
+			r.seen = map[string]bool{}
+		}
+		err := r.run(r.table.initial)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(r.queue) == 0 {
+		return nil, false, nil
+	}
+	if r.iterations >= r.executor.recursionLimit {
+		return nil, false, fmt.Errorf("recursive common table expression %v exceeded limit of %v rows", r.table.name, r.executor.recursionLimit)
+	}
+	r.iterations++
+
+	row := r.queue[0]
+	r.queue[0] = nil
+	r.queue = r.queue[1:]
+
+	r.table.working.rows = [][]any{row}
+	err := r.run(r.table.step)
+	if err != nil {
+		return nil, false, err
+	}
+	return row, true, nil
+}
+
+// run executes select and adds its rows to the queue
+func (r *recursiveIterator) run(plan executionPlan) error {
+	rows, err := r.executor.iterator(plan)
+	if err != nil {
+		return err
+	}
+	defer rows.close()
+
+	for {
+		row, ok, err := rows.next()
+		if err != nil || !ok {
+			return err
+		}
+		r.add(row)
+	}
+}
+
+// add puts row at the end of the queue, UNION discards rows equal to added ones using column collations
+func (r *recursiveIterator) add(row []any) {
+	if r.seen != nil {
+		key := rowKey(row, r.table.collations)
+		if r.seen[key] {
+			return
+		}
+		r.seen[key] = true
+	}
+	r.queue = append(r.queue, row)
+}
+
+func (r *recursiveIterator) close() error {
+	r.queue, r.seen = nil, nil
+	return nil
+}
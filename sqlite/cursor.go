@@ -0,0 +1,171 @@
+package sqlite
+
+import "sort"
+
+// cursorFrame is position in one page of the path from the root to the current cell
+type cursorFrame struct {
+	page btreePage
+	// index is the next cell or child to visit
+	index int
+	// childVisited is set when left child of the cell at index was already visited, used by index btrees
+	childVisited bool
+}
+
+// tableCursor walks table btree in rowid order, pages are read only when the cursor reaches them
+// and subtrees outside of the rowid range are skipped
+type tableCursor struct {
+	reader fileReader
+	keys   keyRange
+	stack  []cursorFrame
+}
+
+// newTableCursor returns cursor over rows which rowid is in the range, zero range contains all rowids
+func (r fileReader) newTableCursor(rootPage int, keys keyRange) (*tableCursor, error) {
+	page, err := r.readPage(rootPage)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &tableCursor{reader: r, keys: keys}
+	cursor.push(page)
+	return cursor, nil
+}
+
+// push starts visiting the page from the first cell which can hold rowids above lower bound,
+// interior cell key is the largest rowid in its left child
+func (c *tableCursor) push(page btreePage) {
+	cells := page.cells
+	start := sort.Search(len(cells), func(i int) bool { return c.keys.aboveLower(cells[i].rowId) })
+	c.stack = append(c.stack, cursorFrame{page: page, index: start})
+}
+
+// next returns the following row, false is returned when there are no more rows in the range
+func (c *tableCursor) next() (btreeCell, bool, error) {
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		cells := top.page.cells
+
+		if !top.page.btreeHeader.isInterior() {
+			if top.index == len(cells) {
+				c.stack = c.stack[:len(c.stack)-1]
+				continue
+			}
+
+			cell := cells[top.index]
+			top.index++
+			if !c.keys.belowUpper(cell.rowId) {
+				c.stack = nil
+				return btreeCell{}, false, nil
+			}
+			return cell, true, nil
+		}
+
+		// children following a cell above upper bound hold only bigger rowids
+		if top.index > len(cells) || (top.index > 0 && !c.keys.belowUpper(cells[top.index-1].rowId)) {
+			c.stack = c.stack[:len(c.stack)-1]
+			continue
+		}
+
+		child, err := c.reader.readChildPage(top.page.childPointer(top.index))
+		if err != nil {
+			return btreeCell{}, false, err
+		}
+		top.index++
+		c.push(child)
+	}
+
+	return btreeCell{}, false, nil
+}
+
+// indexCursor walks index entries which first key column is in the range, in key order.
+// Interior index cells carry entries too, they are placed between their left child and the next child.
+type indexCursor struct {
+	reader fileReader
+	keys   keyRange
+	stack  []cursorFrame
+	// key is the first key column of the entry returned by next
+	key any
+}
+
+func (r fileReader) newIndexCursor(rootPage int, keys keyRange) (*indexCursor, error) {
+	keys.compare = r.compareStoredKeys
+	page, err := r.readPage(rootPage)
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexCursor{reader: r, keys: keys, stack: []cursorFrame{{page: page}}}, nil
+}
+
+// next returns rowid of the following index entry, false is returned when there are no more entries in the range
+func (c *indexCursor) next() (int64, bool, error) {
+	for len(c.stack) > 0 {
+		top := &c.stack[len(c.stack)-1]
+		cells := top.page.cells
+		isInterior := top.page.btreeHeader.isInterior()
+
+		if top.index == len(cells) && (!isInterior || top.childVisited) {
+			c.stack = c.stack[:len(c.stack)-1]
+			continue
+		}
+
+		if isInterior && !top.childVisited {
+			top.childVisited = true
+
+			// left child holds keys smaller or equal to the cell key, right most child holds the rest
+			visitChild := top.index == len(cells)
+			if !visitChild {
+				key, _, err := c.reader.indexEntry(cells[top.index])
+				if err != nil {
+					return 0, false, err
+				}
+				visitChild = c.keys.aboveLower(key)
+			}
+
+			if visitChild {
+				child, err := c.reader.readChildPage(top.page.childPointer(top.index))
+				if err != nil {
+					return 0, false, err
+				}
+				c.stack = append(c.stack, cursorFrame{page: child})
+			}
+			continue
+		}
+
+		key, rowid, err := c.reader.indexEntry(cells[top.index])
+		if err != nil {
+			return 0, false, err
+		}
+		top.index++
+		top.childVisited = false
+
+		if !c.keys.belowUpper(key) {
+			c.stack = nil
+			return 0, false, nil
+		}
+		if c.keys.aboveLower(key) {
+			c.key = key
+			return rowid, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// indexEntry returns the first key column and the rowid, which is the last column of index record
+func (r fileReader) indexEntry(cell btreeCell) (any, int64, error) {
+	record, err := r.cellRecord(cell)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(record) < 2 {
+		return nil, 0, corruptError("index entry should have key and rowid")
+	}
+
+	rowid, ok := record[len(record)-1].(int64)
+	if !ok {
+		return nil, 0, corruptError("index entry rowid should be integer")
+	}
+
+	return record[0], rowid, nil
+}
@@ -0,0 +1,239 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testPageSize = 512
+
+// buildPage lays out btree page with cells stored at the end of the page, first page starts after database header
+func buildPage(pageNumber int, pageType byte, cells [][]byte, rightMostPointer uint32) []byte {
+	page := make([]byte, testPageSize)
+	offset := 0
+	if pageNumber == 1 {
+		offset = 100
+	}
+
+	headerSize := 8
+	if pageType == 0x02 || pageType == 0x05 {
+		headerSize = 12
+		binary.BigEndian.PutUint32(page[offset+8:], rightMostPointer)
+	}
+
+	page[offset] = pageType
+	binary.BigEndian.PutUint16(page[offset+3:], uint16(len(cells)))
+	contentStart := testPageSize
+	for i, cell := range cells {
+		contentStart -= len(cell)
+		copy(page[contentStart:], cell)
+		binary.BigEndian.PutUint16(page[offset+headerSize+2*i:], uint16(contentStart))
+	}
+	binary.BigEndian.PutUint16(page[offset+5:], uint16(contentStart))
+
+	return page
+}
+
+func tableLeafCell(rowid int64, values ...any) []byte {
+	record := encodeRecord(values)
+	cell := appendVarint(nil, uint64(len(record)))
+	cell = appendVarint(cell, uint64(rowid))
+	return append(cell, record...)
+}
+
+func tableInteriorCell(leftChild uint32, rowid int64) []byte {
+	cell := binary.BigEndian.AppendUint32(nil, leftChild)
+	return appendVarint(cell, uint64(rowid))
+}
+
+func indexCell(leftChild uint32, values ...any) []byte {
+	cell := []byte{}
+	if leftChild != 0 {
+		cell = binary.BigEndian.AppendUint32(cell, leftChild)
+	}
+	record := encodeRecord(values)
+	cell = appendVarint(cell, uint64(len(record)))
+	return append(cell, record...)
+}
+
+// buildTestDatabase writes database with table t (a, b) of 9 rows in three leaves and index on b in two levels
+func buildTestDatabase(t *testing.T) string {
+	t.Helper()
+
+	schema := buildPage(1, 0x0d, [][]byte{
+		tableLeafCell(1, "table", "t", "t", int64(2), "CREATE TABLE t (a integer, b text)"),
+		tableLeafCell(2, "index", "idx_b", "t", int64(6), "CREATE INDEX idx_b ON t (b)"),
+	}, 0)
+
+	leaves := [][][]byte{}
+	for leaf := int64(0); leaf < 3; leaf++ {
+		cells := [][]byte{}
+		for rowid := leaf*3 + 1; rowid <= leaf*3+3; rowid++ {
+			cells = append(cells, tableLeafCell(rowid, nil, fmt.Sprintf("v%v", rowid)))
+		}
+		leaves = append(leaves, cells)
+	}
+
+	indexLeaf := [][]byte{}
+	for rowid := int64(4); rowid <= 9; rowid++ {
+		indexLeaf = append(indexLeaf, indexCell(0, fmt.Sprintf("v%v", rowid), rowid))
+	}
+
+	pages := [][]byte{
+		schema,
+		buildPage(2, 0x05, [][]byte{tableInteriorCell(3, 3), tableInteriorCell(4, 6)}, 5),
+		buildPage(3, 0x0d, leaves[0], 0),
+		buildPage(4, 0x0d, leaves[1], 0),
+		buildPage(5, 0x0d, leaves[2], 0),
+		buildPage(6, 0x02, [][]byte{indexCell(7, "v3", int64(3))}, 8),
+		buildPage(7, 0x0a, [][]byte{indexCell(0, "v1", int64(1)), indexCell(0, "v2", int64(2))}, 0),
+		buildPage(8, 0x0a, indexLeaf, 0),
+	}
+
+	return writeTestDatabase(t, pages)
+}
+
+// testDatabaseHeader returns header of utf-8 database with pages of testPageSize bytes
+func testDatabaseHeader() []byte {
+	header := make([]byte, 100)
+	copy(header, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(header[16:], testPageSize)
+	header[21], header[22], header[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(header[56:], utf8Encoding)
+	return header
+}
+
+// writeTestDatabase writes pages to database file in temporary directory, database header is put on the first page
+func writeTestDatabase(t *testing.T, pages [][]byte) string {
+	t.Helper()
+
+	copy(pages[0], testDatabaseHeader())
+	databaseFilePath := filepath.Join(t.TempDir(), "test.db")
+	content := []byte{}
+	for _, page := range pages {
+		content = append(content, page...)
+	}
+	err := os.WriteFile(databaseFilePath, content, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return databaseFilePath
+}
+
+func TestTableCursorSkipsPagesOutsideRange(t *testing.T) {
+	reader, err := newReader(buildTestDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	testCases := []struct {
+		keys     keyRange
+		expected []int64
+	}{
+		{keys: keyRange{}, expected: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{keys: keyRange{lower: &bound{value: int64(3)}, upper: &bound{value: int64(7), inclusive: true}}, expected: []int64{4, 5, 6, 7}},
+		{keys: keyRange{lower: &bound{value: int64(9), inclusive: true}}, expected: []int64{9}},
+		{keys: keyRange{lower: &bound{value: nil}, upper: &bound{value: int64(1)}}, expected: []int64{}},
+	}
+
+	for _, testCase := range testCases {
+		cursor, err := reader.newTableCursor(2, testCase.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rowids := []int64{}
+		for {
+			cell, ok, err := cursor.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			rowids = append(rowids, cell.rowId)
+		}
+
+		if !reflect.DeepEqual(rowids, testCase.expected) {
+			t.Errorf("expected rowids %v, got: %v", testCase.expected, rowids)
+		}
+	}
+}
+
+func TestIndexCursor(t *testing.T) {
+	reader, err := newReader(buildTestDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	testCases := []struct {
+		keys     keyRange
+		expected []int64
+	}{
+		{keys: keyRange{}, expected: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{keys: keyRange{lower: &bound{value: "v2"}, upper: &bound{value: "v5", inclusive: true}}, expected: []int64{3, 4, 5}},
+		{keys: keyRange{lower: &bound{value: "v3", inclusive: true}, upper: &bound{value: "v3", inclusive: true}}, expected: []int64{3}},
+		{keys: keyRange{lower: &bound{value: "v8"}}, expected: []int64{9}},
+	}
+
+	for _, testCase := range testCases {
+		cursor, err := reader.newIndexCursor(6, testCase.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rowids := []int64{}
+		for {
+			rowid, ok, err := cursor.next()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				break
+			}
+			rowids = append(rowids, rowid)
+		}
+
+		if !reflect.DeepEqual(rowids, testCase.expected) {
+			t.Errorf("expected rowids %v, got: %v", testCase.expected, rowids)
+		}
+	}
+}
+
+func TestLimitStopsReadingPages(t *testing.T) {
+	db, err := Open(buildTestDatabase(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT b FROM t LIMIT 2 OFFSET 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []any{}
+	for rows.Next() {
+		values = append(values, rows.Values()[0])
+	}
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	if !reflect.DeepEqual(values, []any{"v2", "v3"}) {
+		t.Errorf("expected second and third row, got: %v", values)
+	}
+
+	for _, pageNumber := range []int{4, 5} {
+		if _, ok := db.reader.cache.get(pageNumber); ok {
+			t.Errorf("expected page %v not to be read", pageNumber)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package sqlite
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// DB is read only handle to sqlite database file
+type DB struct {
+	reader fileReader
+	// limits of executors running queries
+	sortMemoryLimit      int
+	aggregateMemoryLimit int
+	// recursionLimit is number of rows recursive common table expression can produce
+	recursionLimit int
+}
+
+// Open opens database file, it doesn't change the file in any way
+func Open(databaseFilePath string) (*DB, error) {
+	reader, err := newReader(databaseFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{
+		reader:               reader,
+		sortMemoryLimit:      defaultSortMemoryLimit,
+		aggregateMemoryLimit: defaultAggregateMemoryLimit,
+		recursionLimit:       defaultRecursionLimit,
+	}, nil
+}
+
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
+
+// PageSize returns size of database page in bytes
+func (db *DB) PageSize() int {
+	return db.reader.pageSize
+}
+
+// SetCacheSize sets number of pages kept in page cache, pages cached so far are dropped.
+// Size suggested by database header is used by default.
+func (db *DB) SetCacheSize(pages int) {
+	db.reader.cache = newPageCache(pages)
+}
+
+// SetSortMemoryLimit sets number of bytes of rows ORDER BY keeps in memory, the other rows
+// are sorted in temporary files. Limit is at least 1 byte.
+func (db *DB) SetSortMemoryLimit(bytes int) {
+	db.sortMemoryLimit = max(bytes, 1)
+}
+
+// SetAggregateMemoryLimit sets number of bytes of groups GROUP BY keeps in memory, rows of the other groups
+// are written to temporary files. Limit is at least 1 byte.
+func (db *DB) SetAggregateMemoryLimit(bytes int) {
+	db.aggregateMemoryLimit = max(bytes, 1)
+}
+
+// SetRecursionLimit sets number of rows recursive common table expression can produce,
+// queries which need more rows fail instead of running for a long time
+func (db *DB) SetRecursionLimit(limit int) {
+	db.recursionLimit = limit
+}
+
+// SchemaObject is entry of sqlite_schema table, index or trigger has name of its table in TableName
+type SchemaObject struct {
+	Type      string
+	Name      string
+	TableName string
+}
+
+// Schema returns all entries of sqlite_schema table in schema order
+func (db *DB) Schema() ([]SchemaObject, error) {
+	schemas, err := db.reader.getSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []SchemaObject{}
+	for _, schema := range schemas {
+		objects = append(objects, SchemaObject{Type: schema.schemaType, Name: schema.schemaName, TableName: schema.tableName})
+	}
+
+	return objects, nil
+}
+
+// Tables returns names of all tables in schema order, internal sqlite tables are included
+func (db *DB) Tables() ([]string, error) {
+	schemas, err := db.reader.getSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []string{}
+	for _, schema := range schemas {
+		if schema.schemaType == "table" {
+			tables = append(tables, schema.tableName)
+		}
+	}
+
+	return tables, nil
+}
+
+// Query runs select statement and returns its result set. Returned errors can be
+// checked with errors.Is against ErrSyntax, ErrNoSuchTable, ErrNoSuchColumn, ErrCorrupt and ErrUnsupported.
+func (db *DB) Query(sql string) (*Rows, error) {
+	statement, err := parseSqlStatement(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	query, ok := statement.(selectStatement)
+	if !ok {
+		return nil, unsupportedError("only select statement can be queried")
+	}
+
+	executor := newExecutor(db.reader)
+	executor.sortMemoryLimit = db.sortMemoryLimit
+	executor.aggregateMemoryLimit = db.aggregateMemoryLimit
+	executor.recursionLimit = db.recursionLimit
+
+	planner := createPlanner(db.reader)
+	planner.executor = executor
+	executionPlan, err := planner.preparePlan(query)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator, err := executor.iterator(executionPlan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rows{columns: executionPlan.columns, iterator: iterator}, nil
+}
+
+// Rows is result of a query, use Next to move to the following row and Scan to read its values.
+// Rows are read from the database only when they are requested, Close must be called when the caller
+// stops reading before the last row.
+type Rows struct {
+	columns  []string
+	iterator rowIterator
+	current  []any
+	err      error
+	closed   bool
+}
+
+// Columns returns names of result columns
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Next moves to the next row, false is returned when there are no more rows or reading failed,
+// use Err to tell these cases apart
+func (r *Rows) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	row, ok, err := r.iterator.next()
+	if err != nil || !ok {
+		r.err = err
+		r.current = nil
+		r.Close()
+		return false
+	}
+
+	r.current = row
+	return true
+}
+
+// Err returns error which stopped Next, it is nil when all rows were read
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Values returns values of the current row: nil, int64, float64, string or []byte
+func (r *Rows) Values() []any {
+	return r.current
+}
+
+// Scan copies values of the current row into dest, it supports pointers to
+// any, string, []byte, int64, int, float64 and bool
+func (r *Rows) Scan(dest ...any) error {
+	if r.current == nil {
+		return fmt.Errorf("scan called without calling next")
+	}
+
+	row := r.current
+	if len(dest) != len(row) {
+		return fmt.Errorf("expected %v destination arguments in scan, got: %v", len(row), len(dest))
+	}
+
+	for i, val := range row {
+		err := scanValue(val, dest[i])
+		if err != nil {
+			return fmt.Errorf("scan column %v: %w", r.columns[i], err)
+		}
+	}
+
+	return nil
+}
+
+// Close releases resources used by the query, temporary files of sorting are removed
+func (r *Rows) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return r.iterator.close()
+}
+
+func scanValue(val any, dest any) error {
+	switch d := dest.(type) {
+	case *any:
+		if blob, ok := val.([]byte); ok {
+			val = append([]byte{}, blob...)
+		}
+		*d = val
+	case *string:
+		switch v := val.(type) {
+		case string:
+			*d = v
+		case []byte:
+			*d = string(v)
+		case int64, float64:
+			*d = formatNumber(v)
+		case nil:
+			return fmt.Errorf("null can't be scanned into string")
+		}
+	case *[]byte:
+		switch v := val.(type) {
+		case []byte:
+			*d = append([]byte{}, v...)
+		case string:
+			*d = []byte(v)
+		case int64, float64:
+			*d = []byte(formatNumber(v))
+		case nil:
+			*d = nil
+		}
+	case *int64:
+		intVal, err := toInteger(val)
+		if err != nil {
+			return err
+		}
+		*d = intVal
+	case *int:
+		intVal, err := toInteger(val)
+		if err != nil {
+			return err
+		}
+		*d = int(intVal)
+	case *float64:
+		switch v := val.(type) {
+		case int64:
+			*d = float64(v)
+		case float64:
+			*d = v
+		default:
+			return fmt.Errorf("can't convert %v to float64", reflect.TypeOf(val))
+		}
+	case *bool:
+		intVal, err := toInteger(val)
+		if err != nil {
+			return err
+		}
+		*d = intVal != 0
+	default:
+		return fmt.Errorf("unsupported scan destination: %v", reflect.TypeOf(dest))
+	}
+
+	return nil
+}
+
+func toInteger(val any) (int64, error) {
+	switch v := val.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("can't convert %v to integer", reflect.TypeOf(val))
+	}
+}
+
+func formatNumber(val any) string {
+	if intVal, ok := val.(int64); ok {
+		return strconv.FormatInt(intVal, 10)
+	}
+	return strconv.FormatFloat(val.(float64), 'g', -1, 64)
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryScan(t *testing.T) {
+	db, err := Open("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, name FROM apples WHERE color = 'Red'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if !reflect.DeepEqual(rows.Columns(), []string{"id", "name"}) {
+		t.Errorf("Expected columns to be id and name, got: %v", rows.Columns())
+	}
+
+	if !rows.Next() {
+		t.Fatalf("Expected to get one row")
+	}
+
+	var id int64
+	var name string
+	err = rows.Scan(&id, &name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id != 2 || name != "Fuji" {
+		t.Errorf("Expected row to be 2|Fuji, got: %v|%v", id, name)
+	}
+
+	if rows.Next() {
+		t.Errorf("Expected to get only one row")
+	}
+}
+
+func TestQueryColumnNames(t *testing.T) {
+	db, err := Open("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testCases := map[string][]string{
+		"SELECT * FROM apples":                                     {"id", "name", "color"},
+		"SELECT a.*, 1 FROM apples a":                              {"id", "name", "color", "1"},
+		`SELECT NAME, apples.Color, "id", rowid, id+1 FROM apples`: {"name", "color", "id", "id", "id+1"},
+		`SELECT name AS "Nm", id 'x y' FROM apples AS t`:           {"Nm", "x y"},
+		"SELECT count(*) c, max(id) FROM apples":                   {"c", "max(id)"},
+		"SELECT apples.id FROM apples AS a":                        nil,
+		"SELECT a.* FROM apples":                                   nil,
+	}
+
+	for query, expected := range testCases {
+		rows, err := db.Query(query)
+		if expected == nil {
+			if err == nil {
+				t.Errorf("Expected %q to fail", query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+
+		if !reflect.DeepEqual(rows.Columns(), expected) {
+			t.Errorf("Expected %q to have columns %v, got: %v", query, expected, rows.Columns())
+		}
+	}
+}
+
+func TestOpenNotExistingDatabase(t *testing.T) {
+	_, err := Open("not_existing.db")
+
+	if err == nil {
+		t.Errorf("Expected error when database doesn't exist")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	db, err := Open("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	schema, err := db.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(schema) != 5 {
+		t.Fatalf("Expected 5 schema entries, got: %v", schema)
+	}
+
+	expected := SchemaObject{Type: "index", Name: "idx_orders_customer", TableName: "orders"}
+	if schema[2] != expected {
+		t.Errorf("Expected third entry to be %v, got: %v", expected, schema[2])
+	}
+}
+
+func TestLimits(t *testing.T) {
+	db, err := Open("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.SetCacheSize(1)
+	db.SetSortMemoryLimit(1)
+	db.SetAggregateMemoryLimit(1)
+	db.SetRecursionLimit(3)
+
+	testCases := map[string][]any{
+		"SELECT name FROM apples ORDER BY name DESC LIMIT 2":                                      {"Honeycrisp", "Granny Smith"},
+		"SELECT count(*) FROM apples GROUP BY id % 2 ORDER BY 1":                                  {int64(2), int64(2)},
+		"WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT x FROM n LIMIT 3": {int64(1), int64(2), int64(3)},
+	}
+
+	for query, expected := range testCases {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := []any{}
+		for rows.Next() {
+			values = append(values, rows.Values()[0])
+		}
+		if rows.Err() != nil {
+			t.Fatal(rows.Err())
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Errorf("Expected %q to return %v, got: %v", query, expected, values)
+		}
+	}
+
+	if db.reader.cache.limit != 1 {
+		t.Errorf("Expected page cache to hold 1 page, got: %v", db.reader.cache.limit)
+	}
+
+	db.SetSortMemoryLimit(0)
+	db.SetAggregateMemoryLimit(-1)
+	if db.sortMemoryLimit != 1 || db.aggregateMemoryLimit != 1 {
+		t.Errorf("Expected memory limits to be at least 1 byte, got: %v and %v", db.sortMemoryLimit, db.aggregateMemoryLimit)
+	}
+
+	rows, err := db.Query("WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT x FROM n LIMIT 4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+	}
+	if rows.Err() == nil {
+		t.Errorf("Expected query to exceed recursion limit")
+	}
+}
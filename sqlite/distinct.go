@@ -0,0 +1,146 @@
+package sqlite
+
+import "slices"
+
+// rowKey encodes row so rows equal by comparison using the collations have the same key,
+// null is equal to null here
+func rowKey(row []any, collations []string) string {
+	key := make([]any, len(row))
+	for i, val := range row {
+		key[i] = equalityKey(val, collations[i])
+	}
+	return string(encodeRecord(key))
+}
+
+// distinctIterator discards rows equal to rows returned before. Keys of returned rows are kept in memory,
+// when equal rows come one after another only key of the previous row is kept.
+type distinctIterator struct {
+	source     rowIterator
+	collations []string
+	sorted     bool
+	seen       map[string]bool
+	previous   *string
+}
+
+func (d *distinctIterator) next() ([]any, bool, error) {
+	if d.seen == nil && !d.sorted {
+		d.seen = map[string]bool{}
+	}
+
+	for {
+		row, ok, err := d.source.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		key := rowKey(row, d.collations)
+		if d.sorted {
+			if d.previous == nil || *d.previous != key {
+				d.previous = &key
+				return row, true, nil
+			}
+		} else if !d.seen[key] {
+			d.seen[key] = true
+			return row, true, nil
+		}
+	}
+}
+
+func (d *distinctIterator) close() error {
+	d.seen = nil
+	return d.source.close()
+}
+
+// projectionCollations returns collations result rows are compared with, BINARY is used
+// for expressions without collation
+func projectionCollations(projection []expression) []string {
+	collations := []string{}
+	for _, expr := range projection {
+		collation, _ := exprCollation(expr)
+		if collation == "" {
+			collation = "BINARY"
+		}
+		collations = append(collations, collation)
+	}
+	return collations
+}
+
+// isDistinctSorted checks if equal result rows come one after another, so they can be found without
+// keeping keys of all rows. It is so when the leading ORDER BY keys are the result columns, or when rows
+// of the only result column are read in order of its values from index or table btree.
+func isDistinctSorted(plan executionPlan, collations []string) bool {
+	if len(plan.orderBy) > 0 {
+		covered := make([]bool, len(plan.projection))
+		remaining := len(plan.projection)
+		for _, key := range plan.orderBy {
+			if remaining == 0 {
+				break
+			}
+
+			i := slices.IndexFunc(plan.projection, func(expr expression) bool { return sameExpr(expr, key.expr) })
+			if i == -1 || !(key.collation == collations[i] || isBinaryCollation(key.collation) && collations[i] == "BINARY") {
+				return false
+			}
+			if !covered[i] {
+				covered[i] = true
+				remaining--
+			}
+		}
+		return remaining == 0
+	}
+
+	if plan.isAggregate() || len(plan.projection) != 1 {
+		return false
+	}
+	column, ok := plan.projection[0].(boundColumnExpr)
+	return ok && isBinaryCollation(collations[0]) && isScanOrderedBy(plan.from, column.index)
+}
+
+// distinctScanColumn returns column of the scanned table when the query returns its distinct values
+// and uses no other column except rowid, nil is returned otherwise
+func distinctScanColumn(plan executionPlan, scan tableScan) *int {
+	if !plan.distinct || plan.isAggregate() || plan.where != nil || len(plan.projection) != 1 || scan.isDerived() {
+		return nil
+	}
+
+	column, ok := plan.projection[0].(boundColumnExpr)
+	index := column.index - scan.offset
+	if !ok || !isBinaryCollation(column.collation) || index < 0 || index >= len(scan.table.columns) || scan.table.columns[index].isRowidAlias() {
+		return nil
+	}
+	for _, key := range plan.orderBy {
+		if !isCoveredByIndex(key.expr, scan, index) {
+			return nil
+		}
+	}
+	return &index
+}
+
+// isCoveredByIndex checks if expression uses only the indexed column and rowid of the scanned table,
+// index entry holds both of them so expression can be evaluated without reading table row
+func isCoveredByIndex(expr expression, scan tableScan, column int) bool {
+	covered := true
+	walkExpr(expr, func(e expression) bool {
+		if bound, ok := e.(boundColumnExpr); ok {
+			index := bound.index - scan.offset
+			isRowid := index == len(scan.table.columns) || index >= 0 && index < len(scan.table.columns) && scan.table.columns[index].isRowidAlias()
+			covered = covered && (index == column || isRowid)
+		}
+		return covered
+	})
+	return covered
+}
+
+// isScanOrderedBy checks if scan returns rows ordered by values of the column at the position of joined row.
+// Joins keep order of rows of the first table, so scan of the first table decides order of joined rows too.
+func isScanOrderedBy(scan tableScan, index int) bool {
+	column := index - scan.offset
+	if scan.isDerived() || column < 0 || column > len(scan.table.columns) {
+		return false
+	}
+
+	if scan.indexSeek != nil {
+		return scan.indexSeek.column == column
+	}
+	return column == len(scan.table.columns) || scan.table.columns[column].isRowidAlias()
+}
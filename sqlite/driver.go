@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+var errReadOnly = errors.New("database is opened read only, only select statements are supported")
+
+var (
+	_ driver.Driver         = (*Driver)(nil)
+	_ driver.Conn           = (*conn)(nil)
+	_ driver.QueryerContext = (*conn)(nil)
+	_ driver.Stmt           = (*stmt)(nil)
+	_ driver.Rows           = (*rows)(nil)
+)
+
+func init() {
+	sql.Register("sqlite", &Driver{})
+}
+
+// Driver implements database/sql driver, data source name is path to the database file
+type Driver struct{}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	db, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{db: db}, nil
+}
+
+type conn struct {
+	db *DB
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+func (c *conn) Close() error {
+	return c.db.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, errReadOnly
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if len(args) > 0 {
+		return nil, errors.New("query parameters are not supported")
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{rows: result}, nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput returns 0 because placeholders are not supported by the parser
+func (s *stmt) NumInput() int {
+	return 0
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errReadOnly
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	namedArgs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		namedArgs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+
+	return s.conn.QueryContext(context.Background(), s.query, namedArgs)
+}
+
+type rows struct {
+	rows *Rows
+}
+
+func (r *rows) Columns() []string {
+	return r.rows.Columns()
+}
+
+func (r *rows) Close() error {
+	return r.rows.Close()
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+
+	for i, val := range r.rows.Values() {
+		dest[i] = driverValue(val)
+	}
+
+	return nil
+}
+
+// driverValue maps record values to driver values, blob is copied because it points to cached page
+func driverValue(val any) driver.Value {
+	if blob, ok := val.([]byte); ok {
+		return append([]byte{}, blob...)
+	}
+
+	// nil, int64, float64 and string are valid driver values already
+	return val
+}
@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestDatabaseSqlDriver(t *testing.T) {
+	db, err := sql.Open("sqlite", "sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT id, name, color FROM apples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(columns, []string{"id", "name", "color"}) {
+		t.Errorf("Expected columns to be id, name and color, got: %v", columns)
+	}
+
+	names := []string{}
+	for rows.Next() {
+		var id int64
+		var name string
+		var color sql.NullString
+		err = rows.Scan(&id, &name, &color)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+
+	if rows.Err() != nil {
+		t.Fatal(rows.Err())
+	}
+
+	expected := []string{"Granny Smith", "Fuji", "Honeycrisp", "Golden Delicious"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected names to be %v, got: %v", expected, names)
+	}
+}
+
+func TestDatabaseSqlDriverIsReadOnly(t *testing.T) {
+	db, err := sql.Open("sqlite", "sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec("DELETE FROM apples")
+	if err == nil {
+		t.Errorf("Expected exec to fail on read only database")
+	}
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+)
+
+// sentinel errors, use errors.Is to check which kind of error was returned
+var (
+	ErrSyntax       = errors.New("syntax error")
+	ErrNoSuchTable  = errors.New("no such table")
+	ErrNoSuchColumn = errors.New("no such column")
+	ErrCorrupt      = errors.New("database disk image is malformed")
+	ErrUnsupported  = errors.New("unsupported feature")
+)
+
+// SyntaxError is returned when query can't be tokenized or parsed, position is byte offset in the query
+type SyntaxError struct {
+	Position int
+	Near     string
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Near == "" {
+		return fmt.Sprintf("syntax error at position %v: %v", e.Position, e.Message)
+	}
+	return fmt.Sprintf("near %q at position %v: syntax error: %v", e.Near, e.Position, e.Message)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrSyntax
+}
+
+func noSuchTableError(tableName string) error {
+	return fmt.Errorf("%w: %v", ErrNoSuchTable, tableName)
+}
+
+func noSuchColumnError(columnName string) error {
+	return fmt.Errorf("%w: %v", ErrNoSuchColumn, columnName)
+}
+
+func corruptError(format string, args ...any) error {
+	return fmt.Errorf("%w: %v", ErrCorrupt, fmt.Sprintf(format, args...))
+}
+
+func unsupportedError(format string, args ...any) error {
+	return fmt.Errorf("%w: %v", ErrUnsupported, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueryErrors(t *testing.T) {
+	db, err := Open("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testCases := []struct {
+		query    string
+		expected error
+	}{
+		{query: "SELECT name FROM pears", expected: ErrNoSuchTable},
+		{query: "SELECT weight FROM apples", expected: ErrNoSuchColumn},
+		{query: "SELECT name FROM apples WHERE weight = 1", expected: ErrNoSuchColumn},
+		{query: "SELECT name FROM apples WHERE color = 'Red", expected: ErrSyntax},
+		{query: "SELECT name apples", expected: ErrNoSuchColumn},
+		{query: "SELECT name FROM", expected: ErrSyntax},
+		{query: "DROP TABLE apples", expected: ErrSyntax},
+		{query: "CREATE INDEX idx_color ON apples (color)", expected: ErrUnsupported},
+	}
+
+	for _, testCase := range testCases {
+		_, err := db.Query(testCase.query)
+		if !errors.Is(err, testCase.expected) {
+			t.Errorf("Expected %q to fail with %v, got: %v", testCase.query, testCase.expected, err)
+		}
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	_, err := parseSqlStatement("SELECT name FROM apples WHERE color = 'Red")
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("Expected syntax error, got: %v", err)
+	}
+
+	if syntaxErr.Position != 38 {
+		t.Errorf("Expected error at position 38, got: %v", syntaxErr.Position)
+	}
+}
+
+func TestParseRecordCorrupt(t *testing.T) {
+	// text of 5 bytes in record with only 2 bytes of body
+	_, err := parseRecord([]byte{2, 23, 'h', 'i'})
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected corrupt error, got: %v", err)
+	}
+
+	_, err = parseRecord([]byte{2, 10})
+	if !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected reserved serial type to be corrupt error, got: %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package sqlite
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// boundColumnExpr is column reference resolved by planner to position of the value in the row
+type boundColumnExpr struct {
+	index     int
+	name      string
+	affinity  affinity
+	collation string
+}
+
+// exprAffinity returns affinity used for comparisons, only column references have one
+func exprAffinity(expr expression) affinity {
+	switch e := expr.(type) {
+	case boundColumnExpr:
+		return e.affinity
+	case collateExpr:
+		return exprAffinity(e.expr)
+	case correlatedColumnExpr:
+		return exprAffinity(e.correlation.expr)
+	case *subqueryExpr:
+		// scalar subquery has affinity of its result column
+		return exprAffinity(e.plan.projection[0])
+	default:
+		return noAffinity
+	}
+}
+
+// exprCollation returns collation of expression, explicit one is set by COLLATE operator and it is
+// inherited by expressions using it as operand, column collation applies only to plain column reference
+func exprCollation(expr expression) (collation string, explicit bool) {
+	switch e := expr.(type) {
+	case collateExpr:
+		return e.collation, true
+	case boundColumnExpr:
+		return e.collation, false
+	case correlatedColumnExpr:
+		return exprCollation(e.correlation.expr)
+	case unaryExpr:
+		if e.operator == "+" {
+			return exprCollation(e.operand)
+		}
+		if collation, explicit := exprCollation(e.operand); explicit {
+			return collation, true
+		}
+	case binaryExpr:
+		for _, operand := range []expression{e.left, e.right} {
+			if collation, explicit := exprCollation(operand); explicit {
+				return collation, true
+			}
+		}
+	}
+	return "", false
+}
+
+// comparisonCollation picks collation for comparison of two operands, explicit collation takes
+// precedence over column one and left operand takes precedence over the right one
+func comparisonCollation(left, right expression) string {
+	leftCollation, leftExplicit := exprCollation(left)
+	rightCollation, rightExplicit := exprCollation(right)
+	if leftExplicit || (!rightExplicit && leftCollation != "") {
+		return leftCollation
+	}
+	return rightCollation
+}
+
+// evalExpr evaluates expression against the row, row can be nil for expressions without column references.
+// Values follow sqlite representation: nil, int64, float64, string and []byte.
+func evalExpr(expr expression, row []any) (any, error) {
+	switch e := expr.(type) {
+	case literalExpr:
+		return e.value, nil
+	case boundColumnExpr:
+		if e.index >= len(row) {
+			return nil, fmt.Errorf("column %v is not available", e.name)
+		}
+		return row[e.index], nil
+	case correlatedColumnExpr:
+		return e.correlation.value, nil
+	case columnRefExpr:
+		return nil, noSuchColumnError(e.name)
+	case unaryExpr:
+		operand, err := evalExpr(e.operand, row)
+		if err != nil {
+			return nil, err
+		}
+		return evalUnary(e.operator, operand), nil
+	case binaryExpr:
+		return evalBinary(e, row)
+	case betweenExpr:
+		return evalBetween(e, row)
+	case collateExpr:
+		return evalExpr(e.expr, row)
+	case *subqueryExpr:
+		return evalSubquery(e, row)
+	case existsExpr:
+		return evalExists(e, row)
+	case inExpr:
+		return evalIn(e, row)
+	case functionCallExpr:
+		return nil, unsupportedError("no such function: %v", e.name)
+	default:
+		return nil, unsupportedError("expression %T", expr)
+	}
+}
+
+func evalUnary(operator string, operand any) any {
+	switch operator {
+	case "NOT":
+		if operand == nil {
+			return nil
+		}
+		return boolValue(!isTrue(operand))
+	case "-":
+		switch v := toNumeric(operand).(type) {
+		case int64:
+			if v == math.MinInt64 {
+				return -float64(v)
+			}
+			return -v
+		case float64:
+			return -v
+		default:
+			return nil
+		}
+	default:
+		// unary plus doesn't change the value, not even its type
+		return operand
+	}
+}
+
+func evalBinary(e binaryExpr, row []any) (any, error) {
+	left, err := evalExpr(e.left, row)
+	if err != nil {
+		return nil, err
+	}
+
+	// right side isn't evaluated when result is known from the left one
+	if e.operator == "AND" && left != nil && !isTrue(left) {
+		return int64(0), nil
+	}
+	if e.operator == "OR" && left != nil && isTrue(left) {
+		return int64(1), nil
+	}
+
+	right, err := evalExpr(e.right, row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator {
+	case "AND":
+		if right != nil && !isTrue(right) {
+			return int64(0), nil
+		}
+		if left == nil || right == nil {
+			return nil, nil
+		}
+		return int64(1), nil
+	case "OR":
+		if right != nil && isTrue(right) {
+			return int64(1), nil
+		}
+		if left == nil || right == nil {
+			return nil, nil
+		}
+		return int64(0), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		affinity := comparisonAffinity(exprAffinity(e.left), exprAffinity(e.right))
+		collation := comparisonCollation(e.left, e.right)
+		return compareOperator(e.operator, applyAffinity(affinity, left), applyAffinity(affinity, right), collation, e.encoding), nil
+	case "IS", "IS NOT":
+		affinity := comparisonAffinity(exprAffinity(e.left), exprAffinity(e.right))
+		collation := comparisonCollation(e.left, e.right)
+		return isOperator(e.operator, applyAffinity(affinity, left), applyAffinity(affinity, right), collation, e.encoding), nil
+	case "||":
+		if left == nil || right == nil {
+			return nil, nil
+		}
+		return valueToText(left) + valueToText(right), nil
+	case "+", "-", "*", "/", "%":
+		return arithmetic(e.operator, left, right), nil
+	default:
+		return nil, unsupportedError("operator %v", e.operator)
+	}
+}
+
+func evalBetween(e betweenExpr, row []any) (any, error) {
+	// x BETWEEN low AND high is the same as x >= low AND x <= high
+	and := binaryExpr{
+		operator: "AND",
+		left:     binaryExpr{operator: ">=", left: e.expr, right: e.low, encoding: e.encoding},
+		right:    binaryExpr{operator: "<=", left: e.expr, right: e.high, encoding: e.encoding},
+	}
+
+	result, err := evalBinary(and, row)
+	if err != nil || !e.not {
+		return result, err
+	}
+	return evalUnary("NOT", result), nil
+}
+
+// compareOperator returns 1 or 0, comparison with null is null
+func compareOperator(operator string, left, right any, collation string, encoding uint32) any {
+	if left == nil || right == nil {
+		return nil
+	}
+
+	cmp := compareCollated(left, right, collation, encoding)
+	switch operator {
+	case "=":
+		return boolValue(cmp == 0)
+	case "!=":
+		return boolValue(cmp != 0)
+	case "<":
+		return boolValue(cmp < 0)
+	case "<=":
+		return boolValue(cmp <= 0)
+	case ">":
+		return boolValue(cmp > 0)
+	default:
+		return boolValue(cmp >= 0)
+	}
+}
+
+// isOperator returns 1 or 0, it works as = and != except that null equals null and differs from other values
+func isOperator(operator string, left, right any, collation string, encoding uint32) any {
+	equal := left == nil && right == nil
+	if left != nil && right != nil {
+		equal = compareCollated(left, right, collation, encoding) == 0
+	}
+	return boolValue(equal == (operator == "IS"))
+}
+
+// arithmetic follows sqlite rules: integer operations overflowing 64 bits and operations
+// with real operand give real result, division by zero is null
+func arithmetic(operator string, left, right any) any {
+	if left == nil || right == nil {
+		return nil
+	}
+	left, right = toNumeric(left), toNumeric(right)
+
+	intLeft, isIntLeft := left.(int64)
+	intRight, isIntRight := right.(int64)
+
+	if operator == "%" {
+		// remainder is computed on integers, result is real when any operand is real
+		a, b := int64(toFloat64(left)), int64(toFloat64(right))
+		if b == 0 {
+			return nil
+		}
+		remainder := int64(0)
+		if b != -1 {
+			remainder = a % b
+		}
+		if isIntLeft && isIntRight {
+			return remainder
+		}
+		return float64(remainder)
+	}
+
+	if isIntLeft && isIntRight {
+		if result, ok := integerArithmetic(operator, intLeft, intRight); ok {
+			return result
+		}
+		if operator == "/" && intRight == 0 {
+			return nil
+		}
+	}
+
+	a, b := toFloat64(left), toFloat64(right)
+	var result float64
+	switch operator {
+	case "+":
+		result = a + b
+	case "-":
+		result = a - b
+	case "*":
+		result = a * b
+	case "/":
+		if b == 0 {
+			return nil
+		}
+		result = a / b
+	}
+
+	if math.IsNaN(result) {
+		return nil
+	}
+	return result
+}
+
+// integerArithmetic returns false when result doesn't fit into 64 bits or division is by zero
+func integerArithmetic(operator string, a, b int64) (int64, bool) {
+	switch operator {
+	case "+":
+		result := a + b
+		return result, (a >= 0) != (b >= 0) || (result >= 0) == (a >= 0)
+	case "-":
+		result := a - b
+		return result, (a >= 0) == (b >= 0) || (result >= 0) == (a >= 0)
+	case "*":
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		result := a * b
+		return result, result/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+	default:
+		if b == 0 || (a == math.MinInt64 && b == -1) {
+			return 0, false
+		}
+		return a / b, true
+	}
+}
+
+// isTrue converts value to boolean the same way as sqlite does for where clause, null is false
+func isTrue(val any) bool {
+	switch v := toNumeric(val).(type) {
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return false
+	}
+}
+
+func boolValue(val bool) int64 {
+	if val {
+		return 1
+	}
+	return 0
+}
+
+// toNumeric converts text and blob to number using the longest numeric prefix, text without one is 0
+func toNumeric(val any) any {
+	switch v := val.(type) {
+	case string:
+		return textToNumeric(v)
+	case []byte:
+		return textToNumeric(string(v))
+	default:
+		return val
+	}
+}
+
+func textToNumeric(text string) any {
+	val, _ := numericPrefix(strings.TrimLeft(text, " \t\n\r"))
+	return val
+}
+
+// textToNumber converts text which is well formed number, surrounding spaces are allowed
+func textToNumber(text string) (any, bool) {
+	text = strings.Trim(text, " \t\n\r")
+	val, length := numericPrefix(text)
+	return val, length > 0 && length == len(text)
+}
+
+// numericPrefix parses the longest prefix of text which is a number, length is 0 when there is none
+func numericPrefix(text string) (any, int) {
+	end := 0
+	if end < len(text) && (text[end] == '-' || text[end] == '+') {
+		end++
+	}
+	digitsStart := end
+	for end < len(text) && isDigit(text[end]) {
+		end++
+	}
+	hasDigits := end > digitsStart
+
+	isReal := false
+	if end < len(text) && text[end] == '.' {
+		fractionStart := end + 1
+		fractionEnd := fractionStart
+		for fractionEnd < len(text) && isDigit(text[fractionEnd]) {
+			fractionEnd++
+		}
+		if hasDigits || fractionEnd > fractionStart {
+			end = fractionEnd
+			isReal = true
+			hasDigits = true
+		}
+	}
+
+	if !hasDigits {
+		return int64(0), 0
+	}
+
+	if end < len(text) && (text[end] == 'e' || text[end] == 'E') {
+		exponentEnd := end + 1
+		if exponentEnd < len(text) && (text[exponentEnd] == '-' || text[exponentEnd] == '+') {
+			exponentEnd++
+		}
+		if exponentEnd < len(text) && isDigit(text[exponentEnd]) {
+			for exponentEnd < len(text) && isDigit(text[exponentEnd]) {
+				exponentEnd++
+			}
+			end = exponentEnd
+			isReal = true
+		}
+	}
+
+	prefix := text[:end]
+	if !isReal {
+		if val, err := strconv.ParseInt(prefix, 10, 64); err == nil {
+			return val, end
+		}
+	}
+
+	val, _ := strconv.ParseFloat(prefix, 64)
+	return val, end
+}
+
+// valueToText converts value to text the same way as sqlite does for || operator
+func valueToText(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return realToText(v)
+	case []byte:
+		return string(v)
+	default:
+		return v.(string)
+	}
+}
+
+// realToText mimics "%!.15g" format used by sqlite, real values always have decimal point
+func realToText(val float64) string {
+	if math.IsInf(val, 1) {
+		return "Inf"
+	} else if math.IsInf(val, -1) {
+		return "-Inf"
+	}
+	if val == 0 {
+		// negative zero is printed without sign
+		val = 0
+	}
+
+	formatted := strconv.FormatFloat(val, 'g', 15, 64)
+	if strings.ContainsAny(formatted, ".n") {
+		return formatted
+	}
+
+	if exponent := strings.IndexByte(formatted, 'e'); exponent != -1 {
+		return formatted[:exponent] + ".0" + formatted[exponent:]
+	}
+
+	return formatted + ".0"
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEvalExpr(t *testing.T) {
+	testCases := []struct {
+		expr     string
+		expected any
+	}{
+		{expr: "1 + 2 * 3", expected: int64(7)},
+		{expr: "7 / 2", expected: int64(3)},
+		{expr: "7 / 0", expected: nil},
+		{expr: "7 % 0", expected: nil},
+		{expr: "-7 % 3", expected: int64(-1)},
+		{expr: "1 - 'abc'", expected: int64(1)},
+		{expr: "'12abc' + 1", expected: int64(13)},
+		{expr: "2 || 3 * 4", expected: int64(92)},
+		{expr: "'a' || NULL", expected: nil},
+		{expr: "NULL + 1", expected: nil},
+		{expr: "NULL AND 0", expected: int64(0)},
+		{expr: "NULL AND 1", expected: nil},
+		{expr: "NULL OR 1", expected: int64(1)},
+		{expr: "NULL OR 0", expected: nil},
+		{expr: "NOT NULL", expected: nil},
+		{expr: "NOT 0", expected: int64(1)},
+		{expr: "NULL = NULL", expected: nil},
+		{expr: "NULL IS NULL", expected: int64(1)},
+		{expr: "1 IS NULL", expected: int64(0)},
+		{expr: "NULL IS NOT 1", expected: int64(1)},
+		{expr: "'a' IS NOT 'A' COLLATE NOCASE", expected: int64(0)},
+		{expr: "1 < 'a'", expected: int64(1)},
+		{expr: "2 BETWEEN 1 AND 3", expected: int64(1)},
+		{expr: "2 NOT BETWEEN 1 AND 3", expected: int64(0)},
+		{expr: "9223372036854775807 + 1", expected: float64(math.MaxInt64) + 1},
+		{expr: "-(-9223372036854775807 - 1)", expected: float64(math.MaxInt64) + 1},
+		{expr: "-0.0 || ''", expected: "0.0"},
+	}
+
+	for _, testCase := range testCases {
+		ast, err := parseSqlStatement("SELECT " + testCase.expr + " FROM t")
+		if err != nil {
+			t.Errorf("Expected %q to be parsed, got: %v", testCase.expr, err)
+			continue
+		}
+
+		val, err := evalExpr(ast.(selectStatement).columns[0].expr, nil)
+		if err != nil {
+			t.Errorf("Expected %q to be evaluated, got: %v", testCase.expr, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(val, testCase.expected) {
+			t.Errorf("Expected %q to be %#v, got: %#v", testCase.expr, testCase.expected, val)
+		}
+	}
+}
+
+func TestRealToText(t *testing.T) {
+	testCases := map[float64]string{
+		1:       "1.0",
+		0.5:     "0.5",
+		1e20:    "1.0e+20",
+		-2.25:   "-2.25",
+		1.0 / 3: "0.333333333333333",
+	}
+
+	for val, expected := range testCases {
+		if text := realToText(val); text != expected {
+			t.Errorf("Expected %v to be formatted as %v, got: %v", val, expected, text)
+		}
+	}
+}
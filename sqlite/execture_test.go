@@ -0,0 +1,810 @@
+package sqlite
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestExecutor(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	executor := newExecutor(reader)
+
+	executionPlan := prepareQuery(t, reader, "SELECT name FROM apples WHERE color = 'Red'")
+
+	data, err := executor.execute(executionPlan)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(data) != 1 {
+		t.Fatalf("Expected to see one item, got: %v", len(data))
+	}
+
+	item := data[0][0]
+
+	textVal, ok := item.(string)
+
+	if !ok {
+		t.Errorf("Exepected item to by string, got: %v", reflect.TypeOf(item))
+	}
+
+	if textVal != "Fuji" {
+		t.Errorf("Expect name of item to be Fuji got: %v", textVal)
+	}
+}
+
+func BenchmarkExecute_WithProfiling(b *testing.B) {
+	f, err := os.Create("cpu.prof")
+	if err != nil {
+		b.Fatal("could not create CPU profile: ", err)
+	}
+	defer f.Close()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		b.Fatal("could not start CPU profile: ", err)
+	}
+	defer pprof.StopCPUProfile()
+
+	b.Run("Execute", func(b *testing.B) {
+		reader, err := newReader("sample.db")
+		if err != nil {
+			b.Fatal(err)
+		}
+		executor := newExecutor(reader)
+		executionPlan := prepareQuery(b, reader, "SELECT name FROM apples")
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := executor.execute(executionPlan)
+			if err != nil {
+				b.Fatalf("execute failed: %v", err)
+			}
+		}
+	})
+
+	mf, err := os.Create("mem.prof")
+	if err != nil {
+		b.Fatal("could not create memory profile: ", err)
+	}
+	defer mf.Close()
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(mf); err != nil {
+		b.Fatal("could not write memory profile: ", err)
+	}
+}
+
+func TestExecutorReusesCachedPages(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	executionPlan := prepareQuery(t, reader, "SELECT name FROM apples")
+
+	_, err = executor.execute(executionPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, missesAfterFirstRun := reader.cacheStats()
+
+	_, err = executor.execute(executionPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, misses := reader.cacheStats()
+
+	if misses != missesAfterFirstRun {
+		t.Errorf("expected second run to read all pages from cache, got %v new misses", misses-missesAfterFirstRun)
+	}
+
+	if hits == 0 {
+		t.Errorf("expected cache hits to be counted")
+	}
+}
+
+func TestExecutorEvaluatesExpressions(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	executionPlan := prepareQuery(t, reader, "SELECT id * 10 + 1, name || ' is ' || color FROM apples WHERE color = 'Red' OR NOT id != 4")
+
+	data, err := executor.execute(executionPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]any{{int64(21), "Fuji is Red"}, {int64(41), "Golden Delicious is Yellow"}}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected rows to be %v, got: %v", expected, data)
+	}
+}
+
+func TestExecutorCountStarRespectsWhere(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	executionPlan := prepareQuery(t, reader, "SELECT COUNT(*) FROM apples WHERE id BETWEEN 2 AND 3")
+
+	data, err := executor.execute(executionPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(data, [][]any{{int64(2)}}) {
+		t.Errorf("Expected count to be 2, got: %v", data)
+	}
+}
+
+func TestExecutorComparesWithColumnAffinity(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		// text literal is converted to integer when compared with integer column
+		"SELECT name FROM apples WHERE id = ' 3 '": {{"Honeycrisp"}},
+		// integer literal is converted to text when compared with text column
+		"SELECT id FROM apples WHERE name > 1 AND id <= 1.5": {{int64(1)}},
+		// literals have no affinity, integer is always smaller than text
+		"SELECT id FROM apples WHERE 10 < '9' AND id = 2.0": {{int64(2)}},
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorComparesUtf16Text(t *testing.T) {
+	reader, err := newReader("utf16.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	// text is compared by its utf-16le bytes, 'ł' is stored as 42 01 so it is smaller than 'a' stored as 61 00
+	testCases := map[string][][]any{
+		"SELECT id FROM letters WHERE n < 'a'":               {{int64(2)}, {int64(5)}, {int64(6)}},
+		"SELECT id FROM words WHERE n < 'a'":                 {{int64(6)}, {int64(2)}, {int64(5)}},
+		"SELECT id FROM words WHERE n > 'ł'":                 {{int64(1)}, {int64(3)}, {int64(4)}},
+		"SELECT id FROM letters WHERE n BETWEEN 'A' AND 'b'": {{int64(1)}, {int64(2)}, {int64(5)}},
+		"SELECT id FROM letters ORDER BY n DESC":             {{int64(4)}, {int64(3)}, {int64(1)}, {int64(5)}, {int64(2)}, {int64(6)}},
+		"SELECT min(n), max(n) FROM letters":                 {{"Ā", "ž"}},
+		"SELECT 'ł' < 'a'":                                   {{int64(1)}},
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorOrderBy(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+	// every row is spilled to its own run
+	executor.sortMemoryLimit = 1
+
+	testCases := map[string][][]any{
+		"SELECT name FROM apples ORDER BY name":                                     {{"Fuji"}, {"Golden Delicious"}, {"Granny Smith"}, {"Honeycrisp"}},
+		"SELECT id, color FROM apples WHERE id > 1 ORDER BY color DESC":             {{int64(4), "Yellow"}, {int64(2), "Red"}, {int64(3), "Blush Red"}},
+		"SELECT id FROM apples ORDER BY 2":                                          nil,
+		"SELECT id, name || '!' FROM apples ORDER BY id % 2 DESC, 2 COLLATE NOCASE": {{int64(1), "Granny Smith!"}, {int64(3), "Honeycrisp!"}, {int64(2), "Fuji!"}, {int64(4), "Golden Delicious!"}},
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func prepareQuery(t testing.TB, reader fileReader, query string) executionPlan {
+	t.Helper()
+
+	executionPlan, err := planQuery(t, reader, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return executionPlan
+}
+
+// planQuery parses query, error of the planner is returned so tests can check queries which must fail
+func planQuery(t testing.TB, reader fileReader, query string) (executionPlan, error) {
+	t.Helper()
+
+	statement, err := parseSqlStatement(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return createPlanner(reader).preparePlan(statement.(selectStatement))
+}
+
+// testQueries executes every query and compares its rows, nil expected rows mean the query must fail to plan
+func testQueries(t *testing.T, reader fileReader, executor executor, testCases map[string][][]any) {
+	t.Helper()
+
+	for query, expected := range testCases {
+		plan, err := planQuery(t, reader, query)
+		if expected == nil {
+			if err == nil {
+				t.Errorf("Expected %q to fail", query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := executor.execute(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, expected) {
+			t.Errorf("Expected %q to return %v, got: %v", query, expected, data)
+		}
+	}
+}
+
+func TestExecutorLimit(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		"SELECT id FROM apples LIMIT 2":                                                                            {{int64(1)}, {int64(2)}},
+		"SELECT id FROM apples LIMIT 2 OFFSET 3":                                                                   {{int64(4)}},
+		"SELECT id FROM apples LIMIT 1, 2":                                                                         {{int64(2)}, {int64(3)}},
+		"SELECT id FROM apples LIMIT -1 OFFSET '2'":                                                                {{int64(3)}, {int64(4)}},
+		"SELECT id FROM apples LIMIT 0":                                                                            {},
+		"SELECT name FROM apples ORDER BY name DESC LIMIT 2 OFFSET 1":                                              {{"Granny Smith"}, {"Golden Delicious"}},
+		"SELECT COUNT(*) FROM apples LIMIT 1 OFFSET 1":                                                             {},
+		"SELECT id FROM apples ORDER BY name LIMIT 9223372036854775807 OFFSET 1":                                   {{int64(4)}, {int64(1)}, {int64(3)}},
+		"SELECT id FROM apples UNION ALL SELECT id FROM apples ORDER BY 1 DESC LIMIT 9223372036854775807 OFFSET 5": {{int64(2)}, {int64(1)}, {int64(1)}},
+		"SELECT id FROM apples LIMIT 2.5":                                                                          nil,
+		"SELECT id FROM apples LIMIT 1 OFFSET 'a'":                                                                 nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorGroupBy(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+	// only the first group is kept in memory, rows of other groups are spilled to disk
+	executor.aggregateMemoryLimit = 1
+
+	testCases := map[string][][]any{
+		"SELECT id % 2, COUNT(*) FROM apples GROUP BY id % 2 ORDER BY 1":                        {{int64(0), int64(2)}, {int64(1), int64(2)}},
+		"SELECT ID > 2, COUNT(*) FROM apples WHERE name != 'Fuji' GROUP BY id > 2 ORDER BY 1":   {{int64(0), int64(1)}, {int64(1), int64(2)}},
+		"SELECT color COLLATE NOCASE FROM apples GROUP BY color HAVING COUNT(*) = 1 ORDER BY 1": {{"Blush Red"}, {"Light Green"}, {"Red"}, {"Yellow"}},
+		"SELECT COUNT(*) FROM apples WHERE id > 10 GROUP BY color":                              {},
+		"SELECT COUNT(*) FROM apples WHERE id > 10":                                             {{int64(0)}},
+		"SELECT COUNT(*) FROM apples HAVING COUNT(*) > 4":                                       {},
+		"SELECT name, COUNT(*) FROM apples":                                                     nil,
+		"SELECT color FROM apples GROUP BY id % 2":                                              nil,
+		"SELECT id % 2 FROM apples GROUP BY id % 2 HAVING color = 'Red'":                        nil,
+		"SELECT COUNT(*) FROM apples GROUP BY id ORDER BY name":                                 nil,
+		"SELECT COUNT(*) FROM apples GROUP BY COUNT(*)":                                         nil,
+		"SELECT COUNT(*) FROM apples GROUP BY 1":                                                nil,
+		"SELECT COUNT(*) FROM apples GROUP BY 2":                                                nil,
+		"SELECT name FROM apples HAVING id > 1":                                                 nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorGroupByWithoutMemory(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+	// every pass over spilled rows aggregates only one group in memory
+	executor.aggregateMemoryLimit = 0
+
+	testCases := map[string][][]any{
+		"SELECT customer_id, count(*) FROM orders GROUP BY customer_id ORDER BY 1": {
+			{nil, int64(1)}, {int64(1), int64(2)}, {int64(2), int64(1)}, {int64(3), int64(1)}, {int64(5), int64(1)},
+		},
+		"SELECT city, count(*), sum(id) FROM customers GROUP BY city ORDER BY 1": {
+			{nil, int64(1), int64(4)}, {"berlin", int64(1), int64(2)}, {"Paris", int64(2), int64(4)},
+		},
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorAggregates(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		"SELECT COUNT(*), COUNT(name), SUM(id), TOTAL(id), AVG(id), MIN(name), MAX(name) FROM apples":           {{int64(4), int64(4), int64(10), 10.0, 2.5, "Fuji", "Honeycrisp"}},
+		"SELECT COUNT(DISTINCT color COLLATE NOCASE), COUNT(DISTINCT id % 2), SUM(DISTINCT id % 2) FROM apples": {{int64(4), int64(2), int64(1)}},
+		"SELECT SUM(id), TOTAL(id), AVG(id), MIN(id), MAX(id), GROUP_CONCAT(id) FROM apples WHERE id > 10":      {{nil, 0.0, nil, nil, nil, nil}},
+		"SELECT GROUP_CONCAT(id), GROUP_CONCAT(name, '; ') FROM apples WHERE id < 3":                            {{"1,2", "Granny Smith; Fuji"}},
+		"SELECT id % 2, SUM(id + 0.5), MAX(color) FROM apples GROUP BY id % 2 ORDER BY 1":                       {{int64(0), 7.0, "Yellow"}, {int64(1), 5.0, "Light Green"}},
+		"SELECT MIN(name COLLATE NOCASE) FROM apples WHERE id > 1":                                              {{"Fuji"}},
+		"SELECT SUM(id, name) FROM apples":                                                                      nil,
+		"SELECT TOTAL(*) FROM apples":                                                                           nil,
+		"SELECT GROUP_CONCAT(DISTINCT name, ',') FROM apples":                                                   nil,
+		"SELECT SUM(COUNT(*)) FROM apples":                                                                      nil,
+		"SELECT MAX(id, 2) FROM apples":                                                                         nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorStarAndAliases(t *testing.T) {
+	reader, err := newReader("sample.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		"SELECT * FROM apples WHERE id = 2":                                                {{int64(2), "Fuji", "Red"}},
+		"SELECT Apples.name FROM APPLES WHERE id = 2":                                      {{"Fuji"}},
+		"SELECT a.*, a.id * 10 FROM apples a WHERE a.color = 'Red'":                        {{int64(2), "Fuji", "Red", int64(20)}},
+		"SELECT name AS id FROM apples WHERE id = 2":                                       {{"Fuji"}},
+		"SELECT id AS i FROM apples WHERE i > 2 ORDER BY i DESC":                           {{int64(4)}, {int64(3)}},
+		"SELECT name AS id FROM apples ORDER BY id LIMIT 1":                                {{"Fuji"}},
+		"SELECT id % 2 AS k, COUNT(*) AS n FROM apples GROUP BY k HAVING n > 1 ORDER BY k": {{int64(0), int64(2)}, {int64(1), int64(2)}},
+		"SELECT COUNT(*) AS n FROM apples WHERE n > 1":                                     nil,
+		"SELECT COUNT(*) AS n FROM apples GROUP BY n":                                      nil,
+		"SELECT apples.id FROM apples a":                                                   nil,
+		"SELECT x.* FROM apples":                                                           nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorJoins(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := []struct {
+		query    string
+		strategy joinStrategy
+		expected [][]any
+	}{
+		{
+			query:    "SELECT c.name, o.id FROM customers c JOIN orders o ON o.customer_id = c.id ORDER BY o.id",
+			strategy: indexNestedLoopJoin,
+			expected: [][]any{{"Alice", int64(1)}, {"Alice", int64(2)}, {"Bob", int64(3)}, {"Carol", int64(6)}},
+		},
+		{
+			query:    "SELECT C.name, O.id FROM CUSTOMERS c JOIN Orders o ON o.customer_id = c.id WHERE c.id = 1",
+			strategy: indexNestedLoopJoin,
+			expected: [][]any{{"Alice", int64(1)}, {"Alice", int64(2)}},
+		},
+		{
+			query:    "SELECT o.id, c.name FROM orders o LEFT JOIN customers c ON c.id = o.customer_id",
+			strategy: indexNestedLoopJoin,
+			expected: [][]any{{int64(1), "Alice"}, {int64(2), "Alice"}, {int64(3), "Bob"}, {int64(4), nil}, {int64(5), nil}, {int64(6), "Carol"}},
+		},
+		{
+			// customers city is NOCASE so it matches cities written in other case
+			query:    "SELECT c.name, ci.country FROM customers c JOIN cities ci ON c.city = ci.name ORDER BY c.name",
+			strategy: hashJoin,
+			expected: [][]any{{"Alice", "France"}, {"Bob", "Germany"}, {"Carol", "France"}},
+		},
+		{
+			query:    "SELECT c.name, count(o.id) FROM customers c LEFT JOIN orders o ON o.amount > c.id * 5 GROUP BY c.name ORDER BY c.name",
+			strategy: nestedLoopJoin,
+			expected: [][]any{{"Alice", int64(4)}, {"Bob", int64(3)}, {"Carol", int64(1)}, {"Dave", int64(0)}},
+		},
+		{
+			query:    "SELECT * FROM customers JOIN orders USING (id) WHERE id = 2",
+			strategy: indexNestedLoopJoin,
+			expected: [][]any{{int64(2), "Bob", "berlin", int64(1), 20.0, nil}},
+		},
+		{
+			// customers without orders, IS compares null returned for missing order as a value
+			query:    "SELECT c.name, o.id IS NULL FROM customers c LEFT JOIN orders o ON o.customer_id = c.id WHERE o.id IS NULL",
+			strategy: indexNestedLoopJoin,
+			expected: [][]any{{"Dave", int64(1)}},
+		},
+		{
+			query:    "SELECT count(*) FROM customers, cities",
+			strategy: nestedLoopJoin,
+			expected: [][]any{{int64(12)}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		statement, err := parseSqlStatement(testCase.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := createPlanner(reader).preparePlan(statement.(selectStatement))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strategy := plan.joins[0].strategy; strategy != testCase.strategy {
+			t.Errorf("Expected %q to use join strategy %v, got: %v", testCase.query, testCase.strategy, strategy)
+		}
+
+		data, err := executor.execute(plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, testCase.expected) {
+			t.Errorf("Expected %q to return %v, got: %v", testCase.query, testCase.expected, data)
+		}
+	}
+
+	errorCases := map[string]string{
+		"SELECT id FROM customers, orders":                                                 "ambiguous column name: id",
+		"SELECT * FROM customers JOIN orders USING (nope)":                                 "cannot join using column nope - column not present in both tables",
+		"SELECT * FROM customers LEFT JOIN orders ON orders.id = cities.rowid JOIN cities": "ON clause references tables to its right",
+	}
+	for query, message := range errorCases {
+		statement, err := parseSqlStatement(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = createPlanner(reader).preparePlan(statement.(selectStatement))
+		if err == nil || err.Error() != message {
+			t.Errorf("Expected %q to fail with %q, got: %v", query, message, err)
+		}
+	}
+}
+
+func TestExecutorSubqueries(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		"SELECT name, (SELECT sum(amount) FROM orders o WHERE o.customer_id = c.id) FROM customers c": {
+			{"Alice", 30.5}, {"Bob", 7.25}, {"Carol", 12.0}, {"Dave", nil},
+		},
+		"SELECT name FROM customers c WHERE NOT EXISTS (SELECT 1 FROM orders WHERE customer_id = c.id)": {{"Dave"}},
+		// null returned by subquery makes NOT IN null for values which are not found
+		"SELECT name FROM customers WHERE id NOT IN (SELECT customer_id FROM orders)":                               {},
+		"SELECT name FROM customers WHERE id NOT IN (SELECT customer_id FROM orders WHERE amount > 5)":              {{"Dave"}},
+		"SELECT name FROM customers WHERE id NOT IN (SELECT customer_id FROM orders WHERE customer_id IS NOT NULL)": {{"Dave"}},
+		"SELECT name FROM customers WHERE id IN (3, 1, NULL)":                                                       {{"Alice"}, {"Carol"}},
+		"SELECT name FROM customers WHERE city IN (SELECT name FROM cities)":                                        {{"Alice"}, {"Bob"}, {"Carol"}},
+		"SELECT count(*) FROM orders WHERE amount > (SELECT avg(amount) FROM orders)":                               {{int64(3)}},
+		"SELECT t.n, count(*) FROM orders o JOIN (SELECT id AS i, name AS n FROM customers) t ON o.customer_id = t.i GROUP BY t.n ORDER BY t.n": {
+			{"Alice", int64(2)}, {"Bob", int64(1)}, {"Carol", int64(1)},
+		},
+		"SELECT * FROM (SELECT name, city FROM customers WHERE id > 1) AS t WHERE t.city = 'paris'":   {{"Carol", "Paris"}},
+		"SELECT name FROM customers WHERE id = (SELECT customer_id, id FROM orders)":                  nil,
+		"SELECT name FROM customers c WHERE EXISTS (SELECT 1 FROM orders WHERE customer_id = c.nope)": nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorCachesUncorrelatedSubquery(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	query := "SELECT id FROM orders o WHERE amount > (SELECT avg(amount) FROM orders) AND EXISTS (SELECT 1 FROM customers WHERE id = o.customer_id)"
+	statement, err := parseSqlStatement(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := createPlanner(reader).preparePlan(statement.(selectStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := newExecutor(reader).execute(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]any{{int64(1)}, {int64(2)}, {int64(6)}}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %q to return %v, got: %v", query, expected, data)
+	}
+
+	subqueries := []*subqueryExpr{}
+	walkExpr(plan.from.filter, func(e expression) bool {
+		if subquery, ok := e.(*subqueryExpr); ok {
+			subqueries = append(subqueries, subquery)
+		}
+		return true
+	})
+	if len(subqueries) != 2 {
+		t.Fatalf("Expected two subqueries, got: %v", len(subqueries))
+	}
+	if !subqueries[0].firstCached || subqueries[0].isCorrelated() {
+		t.Errorf("Expected uncorrelated subquery result to be cached")
+	}
+	if subqueries[1].firstCached || !subqueries[1].isCorrelated() {
+		t.Errorf("Expected correlated subquery to be executed for every row")
+	}
+}
+
+func TestExecutorCommonTableExpressions(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	tree := "WITH RECURSIVE tree(id, name, depth) AS (SELECT id, name, 0 FROM categories WHERE id IN (1, 5) " +
+		"UNION ALL SELECT c.id, c.name, depth + 1 FROM categories c JOIN tree ON c.parent_id = tree.id) "
+	testCases := map[string][][]any{
+		"WITH paris AS (SELECT id, name FROM customers WHERE city = 'paris') SELECT name FROM paris": {{"Alice"}, {"Carol"}},
+		"WITH c(a, b) AS (SELECT id, name FROM customers) SELECT b FROM c WHERE a IN (SELECT customer_id FROM orders)": {
+			{"Alice"}, {"Bob"}, {"Carol"},
+		},
+		// later table can use the earlier one and table name is hidden by common table expression
+		"WITH customers AS (SELECT 1 AS id), twice AS (SELECT id * 2 AS id FROM customers) SELECT id FROM twice": {{int64(2)}},
+		tree + "SELECT name, depth FROM tree": {
+			{"Electronics", int64(0)}, {"Books", int64(0)}, {"Computers", int64(1)}, {"Phones", int64(1)},
+			{"Fiction", int64(1)}, {"Laptops", int64(2)}, {"Tablets", int64(2)},
+		},
+		tree + "SELECT count(*) FROM tree t JOIN categories c ON c.parent_id = t.id WHERE t.depth = 1": {{int64(2)}},
+		// ancestors of a category, working table is on the left side of the join
+		"WITH up(id, name, parent_id) AS (SELECT id, name, parent_id FROM categories WHERE name = 'Laptops' " +
+			"UNION ALL SELECT c.id, c.name, c.parent_id FROM up, categories c WHERE c.id = up.parent_id) SELECT name FROM up": {
+			{"Laptops"}, {"Computers"}, {"Electronics"},
+		},
+		// recursion without end is stopped by LIMIT of the query or of the common table expression
+		// categories without parent are roots of the tree
+		"WITH RECURSIVE tree(id, name) AS (SELECT id, name FROM categories WHERE parent_id IS NULL " +
+			"UNION ALL SELECT c.id, c.name FROM categories c JOIN tree ON c.parent_id = tree.id) SELECT name FROM tree": {
+			{"Electronics"}, {"Books"}, {"Computers"}, {"Phones"}, {"Fiction"}, {"Laptops"}, {"Tablets"},
+		},
+		"WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT x FROM n LIMIT 3":          {{int64(1)}, {int64(2)}, {int64(3)}},
+		"WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n LIMIT 2 OFFSET 1) SELECT x FROM n": {{int64(2)}, {int64(3)}},
+		// UNION stops when no new row is produced
+		"WITH RECURSIVE n(x) AS (SELECT 1 UNION SELECT x % 3 + 1 FROM n) SELECT x FROM n": {{int64(1)}, {int64(2)}, {int64(3)}},
+		"WITH RECURSIVE n(x) AS (SELECT 'a' UNION SELECT 'A' FROM n) SELECT x FROM n":     {{"a"}, {"A"}},
+		"SELECT 1 + 2, 'a' || 'b'": {{int64(3), "ab"}},
+		"SELECT 1 WHERE 0":         {},
+		"WITH c(a, b) AS (SELECT id FROM customers) SELECT * FROM c":                                                       nil,
+		"WITH c AS (SELECT 1), c AS (SELECT 2) SELECT * FROM c":                                                            nil,
+		"WITH t(x) AS (SELECT x FROM t) SELECT * FROM t":                                                                   nil,
+		"WITH RECURSIVE t(x) AS (SELECT 1 UNION ALL SELECT t.x + 1 FROM t, t AS u) SELECT * FROM t":                        nil,
+		"WITH RECURSIVE t(x) AS (SELECT 1 UNION ALL SELECT count(*) FROM t) SELECT * FROM t":                               nil,
+		"WITH RECURSIVE t(x) AS (SELECT 1 UNION ALL SELECT x + 1, 2 FROM t) SELECT * FROM t":                               nil,
+		"WITH RECURSIVE t(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM t WHERE x < (SELECT max(x) FROM t)) SELECT * FROM t": nil,
+		"SELECT *": nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorRecursionLimit(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	query := "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 10) SELECT count(*) FROM n"
+	statement, err := parseSqlStatement(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := createPlanner(reader).preparePlan(statement.(selectStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := newExecutor(reader)
+	executor.recursionLimit = 10
+	data, err := executor.execute(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]any{{int64(10)}}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %q to return %v, got: %v", query, expected, data)
+	}
+
+	executor.recursionLimit = 9
+	_, err = executor.execute(plan)
+	if err == nil {
+		t.Errorf("Expected %q to exceed recursion limit", query)
+	}
+}
+
+func TestExecutorCompoundSelect(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		"SELECT id FROM customers UNION SELECT customer_id FROM orders": {
+			{int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}, {int64(5)}, {nil},
+		},
+		"SELECT id FROM customers WHERE id < 3 UNION ALL SELECT customer_id FROM orders WHERE id < 3": {
+			{int64(1)}, {int64(2)}, {int64(1)}, {int64(1)},
+		},
+		"SELECT id FROM customers INTERSECT SELECT customer_id FROM orders": {{int64(1)}, {int64(2)}, {int64(3)}},
+		"SELECT customer_id FROM orders EXCEPT SELECT id FROM customers":    {{int64(5)}, {nil}},
+		"SELECT NULL INTERSECT SELECT NULL":                                 {{nil}},
+		// integer equals integral real but not text or blob with the same digits
+		"SELECT 1 UNION SELECT 1.0 UNION SELECT '1' UNION SELECT x'31'": {{int64(1)}, {"1"}, {[]byte("1")}},
+		// city column is NOCASE so it is used by both selects, name of cities is BINARY
+		"SELECT city FROM customers UNION SELECT name FROM cities":  {{"Paris"}, {"berlin"}, {nil}, {"Rome"}},
+		"SELECT name FROM cities UNION SELECT city FROM customers":  {{"PARIS"}, {"Berlin"}, {"Rome"}, {"Paris"}, {"berlin"}, {nil}},
+		"SELECT city FROM customers EXCEPT SELECT name FROM cities": {{nil}},
+		// operators are applied from left to right
+		"SELECT id FROM customers EXCEPT SELECT 2 UNION ALL SELECT 1": {{int64(1)}, {int64(3)}, {int64(4)}, {int64(1)}},
+		"SELECT id FROM customers UNION SELECT customer_id FROM orders ORDER BY 1 DESC LIMIT 3": {
+			{int64(5)}, {int64(4)}, {int64(3)},
+		},
+		"SELECT id AS k FROM customers UNION ALL SELECT customer_id FROM orders ORDER BY k LIMIT 3 OFFSET 1": {
+			{int64(1)}, {int64(1)}, {int64(1)},
+		},
+		"SELECT name, (SELECT amount FROM orders WHERE customer_id = c.id UNION SELECT 100 ORDER BY 1 LIMIT 1) FROM customers c": {
+			{"Alice", 10.5}, {"Bob", 7.25}, {"Carol", 12.0}, {"Dave", int64(100)},
+		},
+		"WITH RECURSIVE t(a) AS (SELECT 1 UNION ALL SELECT 5 UNION ALL SELECT a + 1 FROM t WHERE a < 3) SELECT * FROM t": {
+			{int64(1)}, {int64(5)}, {int64(2)}, {int64(3)},
+		},
+		"SELECT id, name FROM customers UNION SELECT id FROM orders":                    nil,
+		"SELECT id FROM customers UNION SELECT customer_id FROM orders ORDER BY id + 1": nil,
+		"SELECT id FROM customers UNION SELECT customer_id FROM orders ORDER BY 2":      nil,
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestExecutorDistinct(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string][][]any{
+		// covering index is scanned instead of the table
+		"SELECT DISTINCT customer_id FROM orders":                  {{nil}, {int64(1)}, {int64(2)}, {int64(3)}, {int64(5)}},
+		"SELECT DISTINCT customer_id FROM orders WHERE amount > 5": {{int64(1)}, {int64(2)}, {int64(3)}},
+		"SELECT ALL customer_id FROM orders WHERE customer_id = 1": {{int64(1)}, {int64(1)}},
+		// rows are read from index in order of customer_id
+		"SELECT DISTINCT customer_id FROM orders WHERE customer_id > 0":                                    {{int64(1)}, {int64(2)}, {int64(3)}, {int64(5)}},
+		"SELECT count(DISTINCT customer_id), sum(DISTINCT customer_id) FROM orders WHERE customer_id >= 1": {{int64(4), int64(11)}},
+		// city is NOCASE column
+		"SELECT DISTINCT city FROM customers":                                           {{"Paris"}, {"berlin"}, {nil}},
+		"SELECT DISTINCT name FROM cities UNION ALL SELECT 'Rome'":                      {{"PARIS"}, {"Berlin"}, {"Rome"}, {"Rome"}},
+		"SELECT DISTINCT city FROM customers ORDER BY city DESC LIMIT 2":                {{"Paris"}, {"berlin"}},
+		"SELECT DISTINCT customer_id FROM orders ORDER BY customer_id LIMIT 2 OFFSET 1": {{int64(1)}, {int64(2)}},
+		"SELECT DISTINCT id > 2, 1.0 FROM customers":                                    {{int64(0), 1.0}, {int64(1), 1.0}},
+		"SELECT DISTINCT count(*) FROM orders GROUP BY customer_id":                     {{int64(2)}, {int64(1)}},
+		"SELECT DISTINCT * FROM (SELECT city FROM customers UNION ALL SELECT name FROM cities)": {
+			{"Paris"}, {"berlin"}, {nil}, {"Rome"},
+		},
+	}
+
+	testQueries(t, reader, executor, testCases)
+}
+
+func TestDistinctWithoutHashing(t *testing.T) {
+	reader, err := newReader("shop.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	testCases := map[string]bool{
+		"SELECT DISTINCT customer_id FROM orders WHERE customer_id > 0":                                                true,
+		"SELECT DISTINCT id FROM orders WHERE amount > 5":                                                              true,
+		"SELECT DISTINCT o.customer_id FROM orders o JOIN customers c ON o.customer_id = c.id WHERE o.customer_id = 1": true,
+		"SELECT DISTINCT city, name FROM customers ORDER BY name, city DESC":                                           true,
+		"SELECT DISTINCT customer_id FROM orders":                                                                      true,
+		"SELECT DISTINCT customer_id FROM orders WHERE amount > 5":                                                     false,
+		"SELECT DISTINCT customer_id, amount FROM orders WHERE customer_id > 0":                                        false,
+		"SELECT DISTINCT c.id FROM orders o JOIN customers c ON o.customer_id = c.id":                                  false,
+		"SELECT DISTINCT city, name FROM customers ORDER BY id, name, city":                                            false,
+		"SELECT DISTINCT city FROM customers ORDER BY city COLLATE BINARY":                                             false,
+	}
+
+	for query, expected := range testCases {
+		statement, err := parseSqlStatement(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := createPlanner(reader).preparePlan(statement.(selectStatement))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sorted := isDistinctSorted(plan, projectionCollations(plan.projection)); sorted != expected {
+			t.Errorf("Expected distinct rows of %q to be sorted: %v, got: %v", query, expected, sorted)
+		}
+	}
+
+	query := "SELECT count(DISTINCT customer_id), count(DISTINCT amount) FROM orders WHERE customer_id > 0"
+	statement, err := parseSqlStatement(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := createPlanner(reader).preparePlan(statement.(selectStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sorted := newHashAggregator(plan, utf8Encoding, 0).sortedArguments; !reflect.DeepEqual(sorted, []bool{true, false}) {
+		t.Errorf("Expected only customer_id values of %q to come in order, got: %v", query, sorted)
+	}
+}
+
+func TestDistinctScansCoveringIndex(t *testing.T) {
+	reader, err := newReader("utf16.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	executor := newExecutor(reader)
+
+	testCases := map[string]bool{
+		"SELECT DISTINCT n FROM words":                            true,
+		"SELECT DISTINCT n FROM words WHERE n > 'a' OR id = 2":    true,
+		"SELECT DISTINCT n FROM words WHERE n < 'z' ORDER BY n":   true,
+		"SELECT DISTINCT n FROM words WHERE id > 2":               false,
+		"SELECT DISTINCT n COLLATE NOCASE FROM words":             false,
+		"SELECT DISTINCT n FROM letters":                          false,
+		"SELECT n FROM words":                                     false,
+		"SELECT DISTINCT n FROM words w JOIN letters l USING (n)": false,
+	}
+
+	for query, expected := range testCases {
+		plan := prepareQuery(t, reader, query)
+		indexSeek := plan.from.indexSeek
+		if covering := indexSeek != nil && indexSeek.covering; covering != expected {
+			t.Errorf("Expected %q to scan covering index: %v, got: %v", query, expected, covering)
+		}
+		if expected && indexSeek.indexName != "idx_words_n" {
+			t.Errorf("Expected %q to scan idx_words_n, got: %v", query, indexSeek.indexName)
+		}
+	}
+
+	// values come in order of their utf-16le bytes
+	testQueries(t, reader, executor, map[string][][]any{
+		"SELECT DISTINCT n FROM words":                         {{"Ā"}, {"A"}, {"ł"}, {"b"}, {"z"}, {"ž"}},
+		"SELECT DISTINCT n FROM words WHERE n > 'a' OR id = 2": {{"A"}, {"b"}, {"z"}, {"ž"}},
+	})
+}
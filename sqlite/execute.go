@@ -0,0 +1,469 @@
+package sqlite
+
+type executor struct {
+	reader fileReader
+	// sortMemoryLimit is number of bytes of rows kept in memory by ORDER BY, the rest is spilled to disk
+	sortMemoryLimit int
+	// aggregateMemoryLimit is number of bytes of groups kept in memory by GROUP BY
+	aggregateMemoryLimit int
+	// recursionLimit is number of rows recursive common table expression can take from its queue,
+	// query fails when it needs more
+	recursionLimit int
+}
+
+func newExecutor(reader fileReader) executor {
+	return executor{
+		reader:               reader,
+		sortMemoryLimit:      defaultSortMemoryLimit,
+		aggregateMemoryLimit: defaultAggregateMemoryLimit,
+		recursionLimit:       defaultRecursionLimit,
+	}
+}
+
+// rowIterator produces rows one at a time, rows are computed only when they are requested
+type rowIterator interface {
+	// next returns the following row, false is returned when there are no more rows
+	next() ([]any, bool, error)
+	close() error
+}
+
+// execute returns all result rows, values are in the same order as plan columns
+func (e executor) execute(plan executionPlan) ([][]any, error) {
+	iterator, err := e.iterator(plan)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.close()
+
+	rows := [][]any{}
+	for {
+		row, ok, err := iterator.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return rows, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+// iterator builds chain of iterators executing the plan, btree pages are read only when more rows
+// are requested so LIMIT stops the scan as soon as it has enough rows
+func (e executor) iterator(plan executionPlan) (rowIterator, error) {
+	if plan.compound != nil {
+		return e.compoundRows(plan.compound)
+	}
+
+	rows, err := e.scan(plan.from, plan.width)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range plan.joins {
+		rows = &joinIterator{executor: e, left: rows, step: step, width: plan.width}
+	}
+	if plan.where != nil {
+		rows = &filterIterator{source: rows, where: plan.where}
+	}
+
+	// projection and sort keys of aggregate query are evaluated on aggregated rows
+	if plan.isAggregate() {
+		rows = &aggregateIterator{source: rows, plan: plan, encoding: e.reader.header.textEncoding(), memoryLimit: e.aggregateMemoryLimit}
+	}
+
+	if len(plan.orderBy) > 0 {
+		rows = &sortIterator{source: rows, plan: plan, encoding: e.reader.header.textEncoding(), memoryLimit: e.sortMemoryLimit}
+	} else {
+		rows = &projectIterator{source: rows, projection: plan.projection}
+	}
+
+	if plan.distinct {
+		collations := projectionCollations(plan.projection)
+		rows = &distinctIterator{source: rows, collations: collations, sorted: isDistinctSorted(plan, collations)}
+	}
+
+	if plan.limit >= 0 || plan.offset > 0 {
+		rows = &limitIterator{source: rows, limit: plan.limit, offset: plan.offset}
+	}
+
+	return rows, nil
+}
+
+// cellCursor returns table rows one at a time
+type cellCursor interface {
+	next() (btreeCell, bool, error)
+}
+
+// cellCursor reads table rows using access path chosen by planner
+func (e executor) cellCursor(scan tableScan) (cellCursor, error) {
+	if scan.rowidRange != nil {
+		return e.reader.newTableCursor(scan.rootPage, *scan.rowidRange)
+	}
+
+	if scan.indexSeek != nil {
+		index, err := e.reader.newIndexCursor(scan.indexSeek.rootPage, scan.indexSeek.keys)
+		if err != nil {
+			return nil, err
+		}
+		return &indexSeekCursor{reader: e.reader, index: index, tableRootPage: scan.rootPage}, nil
+	}
+
+	return e.reader.newTableCursor(scan.rootPage, keyRange{})
+}
+
+// scan returns rows of the table matching scan filter, rows are as wide as joined row
+func (e executor) scan(scan tableScan, width int) (rowIterator, error) {
+	if scan.isDerived() {
+		rows, err := e.derivedRows(scan)
+		if err != nil {
+			return nil, err
+		}
+		return &derivedTableIterator{source: rows, scan: scan, width: width}, nil
+	}
+
+	if scan.indexSeek != nil && scan.indexSeek.covering {
+		index, err := e.reader.newIndexCursor(scan.indexSeek.rootPage, scan.indexSeek.keys)
+		if err != nil {
+			return nil, err
+		}
+		return &indexScanIterator{index: index, scan: scan, affinities: columnAffinities(scan.table), width: width}, nil
+	}
+
+	cells, err := e.cellCursor(scan)
+	if err != nil {
+		return nil, err
+	}
+	return newScanIterator(e.reader, cells, scan, width), nil
+}
+
+// indexScanIterator returns rows built from entries of covering index, only the indexed column
+// and rowid have values as the query doesn't use the other columns
+type indexScanIterator struct {
+	index      *indexCursor
+	scan       tableScan
+	affinities []affinity
+	width      int
+}
+
+func (s *indexScanIterator) next() ([]any, bool, error) {
+	column := s.scan.indexSeek.column
+	for {
+		rowid, ok, err := s.index.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		record := make([]any, column+1)
+		record[column] = s.index.key
+		row := make([]any, s.width)
+		copy(row[s.scan.offset:], tableRow(s.scan.table, s.affinities, rowid, record))
+
+		ok, err = matchWhere(s.scan.filter, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return row, true, nil
+		}
+	}
+}
+
+func (s *indexScanIterator) close() error {
+	return nil
+}
+
+// indexSeekCursor fetches table rows pointed by index entries, rows are returned in index order
+type indexSeekCursor struct {
+	reader        fileReader
+	index         *indexCursor
+	tableRootPage int
+}
+
+func (c *indexSeekCursor) next() (btreeCell, bool, error) {
+	for {
+		rowid, ok, err := c.index.next()
+		if err != nil || !ok {
+			return btreeCell{}, false, err
+		}
+
+		cell, ok, err := c.reader.seekRowid(c.tableRootPage, rowid)
+		if err != nil {
+			return btreeCell{}, false, err
+		}
+		if ok {
+			return cell, true, nil
+		}
+	}
+}
+
+// scanIterator returns table rows matching scan filter, table values are placed at their position in joined row
+// and values of other tables are null
+type scanIterator struct {
+	reader     fileReader
+	cells      cellCursor
+	scan       tableScan
+	affinities []affinity
+	width      int
+}
+
+func newScanIterator(reader fileReader, cells cellCursor, scan tableScan, width int) *scanIterator {
+	return &scanIterator{reader: reader, cells: cells, scan: scan, affinities: columnAffinities(scan.table), width: width}
+}
+
+func (s *scanIterator) next() ([]any, bool, error) {
+	for {
+		cell, ok, err := s.cells.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		record, err := s.reader.cellRecord(cell)
+		if err != nil {
+			return nil, false, err
+		}
+
+		row := tableRow(s.scan.table, s.affinities, cell.rowId, record)
+		if len(row) != s.width {
+			joined := make([]any, s.width)
+			copy(joined[s.scan.offset:], row)
+			row = joined
+		}
+
+		ok, err = matchWhere(s.scan.filter, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return row, true, nil
+		}
+	}
+}
+
+func (s *scanIterator) close() error {
+	return nil
+}
+
+// filterIterator returns source rows matching where condition
+type filterIterator struct {
+	source rowIterator
+	where  expression
+}
+
+func (f *filterIterator) next() ([]any, bool, error) {
+	for {
+		row, ok, err := f.source.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		ok, err = matchWhere(f.where, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return row, true, nil
+		}
+	}
+}
+
+func (f *filterIterator) close() error {
+	return f.source.close()
+}
+
+type projectIterator struct {
+	source     rowIterator
+	projection []expression
+}
+
+func (p *projectIterator) next() ([]any, bool, error) {
+	row, ok, err := p.source.next()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	result, err := project(p.projection, row)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
+
+func (p *projectIterator) close() error {
+	return p.source.close()
+}
+
+// limitIterator skips offset rows and stops reading the source after limit rows, negative limit means no limit
+type limitIterator struct {
+	source   rowIterator
+	limit    int64
+	offset   int64
+	returned int64
+}
+
+func (l *limitIterator) next() ([]any, bool, error) {
+	for ; l.offset > 0; l.offset-- {
+		_, ok, err := l.source.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+
+	if l.limit >= 0 && l.returned >= l.limit {
+		return nil, false, nil
+	}
+
+	row, ok, err := l.source.next()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	l.returned++
+	return row, true, nil
+}
+
+func (l *limitIterator) close() error {
+	return l.source.close()
+}
+
+// maximum number of rows kept by top-N sort, bigger limits use sorter which can spill to disk
+const maxTopNRows = 10000
+
+// sortIterator returns rows ordered by ORDER BY keys, all source rows are read on the first call to next.
+// Keys are evaluated on source row and stored in front of the result values until the rows are sorted.
+type sortIterator struct {
+	source      rowIterator
+	plan        executionPlan
+	encoding    uint32
+	memoryLimit int
+	sorter      *sorter
+	sorted      rowSource
+}
+
+func (s *sortIterator) next() ([]any, bool, error) {
+	if s.sorted == nil {
+		err := s.sort()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	row, ok, err := s.sorted.next()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return row[len(s.plan.orderBy):], true, nil
+}
+
+func (s *sortIterator) sort() error {
+	exprs := []expression{}
+	for _, key := range s.plan.orderBy {
+		exprs = append(exprs, key.expr)
+	}
+	exprs = append(exprs, s.plan.projection...)
+
+	// only rows which can be returned are kept when number of rows is limited, duplicates
+	// discarded by DISTINCT would take place of rows which can be returned
+	s.sorter = newSorter(s.plan.orderBy, s.encoding, s.memoryLimit)
+	add := s.sorter.add
+	var top *topN
+	// range is checked before adding, sum of big limit and offset would overflow
+	if s.plan.limit >= 0 && s.plan.limit <= maxTopNRows-s.plan.offset && !s.plan.distinct {
+		top = newTopN(s.sorter, int(s.plan.offset+s.plan.limit))
+		add = top.add
+	}
+
+	for {
+		row, ok, err := s.source.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		sortRow, err := project(exprs, row)
+		if err != nil {
+			return err
+		}
+
+		err = add(sortRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	if top != nil {
+		s.sorted = top.sorted()
+		return nil
+	}
+
+	var err error
+	s.sorted, err = s.sorter.sorted()
+	return err
+}
+
+func (s *sortIterator) close() error {
+	err := s.source.close()
+	if s.sorter != nil {
+		if sorterErr := s.sorter.close(); err == nil {
+			err = sorterErr
+		}
+	}
+	return err
+}
+
+func columnAffinities(table createTableStatement) []affinity {
+	affinities := make([]affinity, len(table.columns))
+	for i, column := range table.columns {
+		affinities[i] = columnAffinity(column.columnType)
+	}
+	return affinities
+}
+
+// tableRow returns values of declared columns followed by the rowid
+func tableRow(table createTableStatement, affinities []affinity, rowid int64, record []any) []any {
+	row := make([]any, len(table.columns)+1)
+	for i, column := range table.columns {
+		// rowid alias is stored as null in the record, columns added later are missing in old records
+		if column.isRowidAlias() {
+			row[i] = rowid
+		} else if i < len(record) {
+			row[i] = record[i]
+		}
+
+		// real columns store integral values as integers to save space
+		if intVal, ok := row[i].(int64); ok && affinities[i] == realAffinity {
+			row[i] = float64(intVal)
+		}
+	}
+	row[len(table.columns)] = rowid
+
+	return row
+}
+
+// matchWhere checks if row satisfies where condition, null result doesn't match
+func matchWhere(where expression, row []any) (bool, error) {
+	if where == nil {
+		return true, nil
+	}
+
+	val, err := evalExpr(where, row)
+	if err != nil {
+		return false, err
+	}
+
+	return isTrue(val), nil
+}
+
+func project(projection []expression, row []any) ([]any, error) {
+	result := make([]any, len(projection))
+	for i, expr := range projection {
+		val, err := evalExpr(expr, row)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = val
+	}
+
+	return result, nil
+}
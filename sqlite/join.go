@@ -0,0 +1,369 @@
+package sqlite
+
+import (
+	"math"
+	"slices"
+)
+
+type joinStrategy int
+
+const (
+	// nestedLoopJoin reads the right table again for every left row
+	nestedLoopJoin joinStrategy = iota
+	// indexNestedLoopJoin seeks right rows in the rowid or index by value computed from left row
+	indexNestedLoopJoin
+	// hashJoin reads the right table once into hash table by values of join keys
+	hashJoin
+)
+
+// joinStep adds rows of the right table to rows of tables on its left
+type joinStep struct {
+	scan tableScan
+	// outer is set for LEFT JOIN, left row without matching right row is returned with nulls for the right table
+	outer bool
+	// condition must hold for joined row, nil when every pair of rows matches
+	condition expression
+	strategy  joinStrategy
+	// lookup finds right rows of index nested loop join
+	lookup *joinLookup
+	// keys are equalities of left and right rows used by hash join
+	keys []joinKey
+}
+
+// joinKey is equality of expression on the left tables and expression on the right table
+type joinKey struct {
+	left  expression
+	right expression
+	// affinity and collation of the comparison, values equal by it have the same hash key
+	affinity  affinity
+	collation string
+}
+
+// joinLookup seeks right rows equal to value of expression evaluated on left row
+type joinLookup struct {
+	expr     expression
+	affinity affinity
+	// index is nil when rowid is looked up
+	index *seekableIndex
+}
+
+// chooseJoinStrategy picks how matching right rows are found. Equality with the rowid or indexed column of the right
+// table is looked up for every left row, other equalities are answered by hash join and anything else by nested loop.
+func (p planner) chooseJoinStrategy(binder *columnBinder, step *joinStep, right int) error {
+	keys := joinKeys(binder, step.condition, right)
+
+	// derived table has neither rowid nor indexes
+	if !step.scan.isDerived() {
+		indexes, err := p.seekableIndexes(step.scan.table)
+		if err != nil {
+			return err
+		}
+
+		// rowid seek is preferred over index one as it doesn't need to read the index
+		for _, key := range keys {
+			lookup := chooseJoinLookup(step.scan, key, indexes)
+			if lookup != nil && (step.lookup == nil || lookup.index == nil) {
+				step.lookup = lookup
+			}
+		}
+	}
+
+	switch {
+	case step.lookup != nil:
+		step.strategy = indexNestedLoopJoin
+	case len(keys) > 0:
+		step.strategy = hashJoin
+		step.keys = keys
+	default:
+		step.strategy = nestedLoopJoin
+	}
+	return nil
+}
+
+// joinKeys returns equalities of join condition which compare the right table with tables on its left
+func joinKeys(binder *columnBinder, condition expression, right int) []joinKey {
+	rightTable := tableSet(1) << right
+	keys := []joinKey{}
+	for _, conjunct := range conjuncts(condition) {
+		equal, ok := conjunct.(binaryExpr)
+		if !ok || equal.operator != "=" {
+			continue
+		}
+
+		key := joinKey{
+			left:      equal.left,
+			right:     equal.right,
+			affinity:  comparisonAffinity(exprAffinity(equal.left), exprAffinity(equal.right)),
+			collation: comparisonCollation(equal.left, equal.right),
+		}
+		leftTables, rightTables := binder.referencedTables(equal.left), binder.referencedTables(equal.right)
+		if leftTables == rightTable {
+			key.left, key.right = key.right, key.left
+			leftTables, rightTables = rightTables, leftTables
+		}
+		if rightTables != rightTable || leftTables == 0 || leftTables&rightTable != 0 {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// chooseJoinLookup returns lookup when the key compares rowid or the first column of an index with left row value
+func chooseJoinLookup(scan tableScan, key joinKey, indexes []seekableIndex) *joinLookup {
+	column, ok := key.right.(boundColumnExpr)
+	if !ok {
+		return nil
+	}
+
+	index := column.index - scan.offset
+	if index == len(scan.table.columns) || scan.table.columns[index].isRowidAlias() {
+		return &joinLookup{expr: key.left, affinity: key.affinity}
+	}
+
+	if !isBinaryCollation(key.collation) || !seekableAffinity(column.affinity, key.affinity) {
+		return nil
+	}
+	for _, seekable := range indexes {
+		if seekable.column == index {
+			return &joinLookup{expr: key.left, affinity: key.affinity, index: &seekable}
+		}
+	}
+	return nil
+}
+
+// seekableAffinity checks if comparison leaves index keys as they are stored, keys have column affinity applied
+// so seek finds all equal keys only when the comparison doesn't convert them to other storage class
+func seekableAffinity(column, comparison affinity) bool {
+	switch {
+	case isNumericAffinity(comparison):
+		return isNumericAffinity(column)
+	case comparison == textAffinity:
+		return column == textAffinity
+	default:
+		return true
+	}
+}
+
+// joinIterator returns every left row joined with each matching right row, left row of LEFT JOIN
+// without matching right row is returned with nulls in place of the right table values
+type joinIterator struct {
+	executor executor
+	left     rowIterator
+	step     joinStep
+	width    int
+	// current is left row which matches are being returned, nil when the next left row has to be read
+	current []any
+	matches rowSource
+	matched bool
+	// hashTable holds right rows by encoded values of join keys, it is built on the first call to next
+	hashTable map[string][][]any
+	// derivedRows hold rows of derived table, subquery is executed only once for nested loop
+	derivedRows [][]any
+}
+
+func (j *joinIterator) next() ([]any, bool, error) {
+	for {
+		if j.current == nil {
+			left, ok, err := j.left.next()
+			if err != nil || !ok {
+				return nil, false, err
+			}
+
+			j.matches, err = j.rightRows(left)
+			if err != nil {
+				return nil, false, err
+			}
+			j.current, j.matched = left, false
+		}
+
+		right, ok, err := j.matches.next()
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			// right table values of left row are still null
+			left := j.current
+			j.current = nil
+			if j.step.outer && !j.matched {
+				return left, true, nil
+			}
+			continue
+		}
+
+		row := slices.Clone(j.current)
+		start, end := j.step.scan.offset, j.step.scan.offset+len(j.step.scan.table.columns)+1
+		copy(row[start:end], right[start:end])
+
+		ok, err = matchWhere(j.step.condition, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			j.matched = true
+			return row, true, nil
+		}
+	}
+}
+
+// rightRows returns right rows which can match the left row, join condition is checked on each of them
+func (j *joinIterator) rightRows(left []any) (rowSource, error) {
+	switch j.step.strategy {
+	case indexNestedLoopJoin:
+		return j.lookup(left)
+	case hashJoin:
+		if j.hashTable == nil {
+			err := j.buildHashTable()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		key, ok, err := j.hashKey(left, true)
+		if err != nil || !ok {
+			return &memorySource{}, err
+		}
+		return &memorySource{rows: j.hashTable[key]}, nil
+	default:
+		if !j.step.scan.isDerived() {
+			return j.executor.scan(j.step.scan, j.width)
+		}
+
+		if j.derivedRows == nil {
+			j.derivedRows = [][]any{}
+			err := j.readRightRows(func(row []any) error {
+				j.derivedRows = append(j.derivedRows, row)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &memorySource{rows: j.derivedRows}, nil
+	}
+}
+
+func (j *joinIterator) lookup(left []any) (rowSource, error) {
+	lookup := j.step.lookup
+	val, err := evalExpr(lookup.expr, left)
+	if err != nil {
+		return nil, err
+	}
+	val = applyAffinity(lookup.affinity, val)
+
+	var cells cellCursor
+	if lookup.index == nil {
+		rowid, ok := rowidValue(val)
+		if !ok {
+			return &memorySource{}, nil
+		}
+		cells = &rowidSeekCursor{reader: j.executor.reader, rootPage: j.step.scan.rootPage, rowid: rowid}
+	} else {
+		// null is never equal to anything
+		if val == nil {
+			return &memorySource{}, nil
+		}
+		keys, err := keyRangeFromCondition("=", val)
+		if err != nil {
+			return nil, err
+		}
+		index, err := j.executor.reader.newIndexCursor(lookup.index.rootPage, keys)
+		if err != nil {
+			return nil, err
+		}
+		cells = &indexSeekCursor{reader: j.executor.reader, index: index, tableRootPage: j.step.scan.rootPage}
+	}
+
+	return newScanIterator(j.executor.reader, cells, j.step.scan, j.width), nil
+}
+
+// rowidValue converts value compared with rowid to integer, other values can't be equal to any rowid
+func rowidValue(val any) (int64, bool) {
+	switch v := val.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// buildHashTable reads all right rows matching scan filter, rows are kept in memory
+func (j *joinIterator) buildHashTable() error {
+	j.hashTable = map[string][][]any{}
+	return j.readRightRows(func(row []any) error {
+		key, ok, err := j.hashKey(row, false)
+		if ok {
+			j.hashTable[key] = append(j.hashTable[key], row)
+		}
+		return err
+	})
+}
+
+// readRightRows passes every right row matching scan filter to add
+func (j *joinIterator) readRightRows(add func(row []any) error) (err error) {
+	rows, err := j.executor.scan(j.step.scan, j.width)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := rows.close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	for {
+		row, ok, err := rows.next()
+		if err != nil || !ok {
+			return err
+		}
+
+		err = add(row)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// hashKey encodes values of join keys of left or right row, values equal by comparison have the same key.
+// Null is never equal to anything so row with null key has no matches.
+func (j *joinIterator) hashKey(row []any, left bool) (string, bool, error) {
+	values := make([]any, len(j.step.keys))
+	for i, key := range j.step.keys {
+		expr := key.right
+		if left {
+			expr = key.left
+		}
+
+		val, err := evalExpr(expr, row)
+		if err != nil || val == nil {
+			return "", false, err
+		}
+		values[i] = equalityKey(applyAffinity(key.affinity, val), key.collation)
+	}
+
+	return string(encodeRecord(values)), true, nil
+}
+
+func (j *joinIterator) close() error {
+	return j.left.close()
+}
+
+// rowidSeekCursor returns the row with the rowid when the table has one
+type rowidSeekCursor struct {
+	reader   fileReader
+	rootPage int
+	rowid    int64
+	done     bool
+}
+
+func (c *rowidSeekCursor) next() (btreeCell, bool, error) {
+	if c.done {
+		return btreeCell{}, false, nil
+	}
+	c.done = true
+	return c.reader.seekRowid(c.rootPage, c.rowid)
+}
@@ -0,0 +1,576 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"math"
+	"unicode/utf16"
+)
+
+type dbHeader struct {
+	headerString                 []byte
+	dbSizeInBytes                uint16
+	fileFormatWriteVer           byte
+	fileFormatReadVer            byte
+	reservedBytes                byte
+	maxPayloadFraction           byte
+	minPayloadFraction           byte
+	leafPayloadFraction          byte
+	fileChangeCounter            []byte
+	dbSizeInPages                []byte
+	pageNumberFirstFreeTrunkPage []byte
+	totalFreeListPages           []byte
+	schemaCookie                 []byte
+	schemaFormatNumber           []byte
+	defaultPageCacheSize         []byte
+	largestRootBtreeVacum        []byte
+	dbTextEncoding               []byte
+	userVersion                  []byte
+	incrementalVacuum            []byte
+	appId                        []byte
+	reservedExpansion            []byte
+	versionValidForNumber        []byte
+	sqlVersionNumber             []byte
+}
+
+type btreeHeader struct {
+	btreeType                    byte
+	startOfFirstFreeblock        []byte
+	numberOfCells                uint16
+	startOfCellContentArea       uint16
+	numberOfFragmenetedFreeBytes byte
+	rightMostPointer             []byte
+}
+
+// btreeCell holds cell header and the part of payload stored in the page, the part is shared with the page cache
+type btreeCell struct {
+	pageNumberLeftChild       []byte
+	rowId                     int64
+	payloadSize               int
+	localPayload              []byte
+	pageNumberOfFirstoverflow []byte
+}
+
+func parseDatabaseHeader(data []byte) dbHeader {
+	return dbHeader{
+		headerString:                 data[0:16],
+		dbSizeInBytes:                binary.BigEndian.Uint16(data[16:18]),
+		fileFormatWriteVer:           data[18],
+		fileFormatReadVer:            data[19],
+		reservedBytes:                data[20],
+		maxPayloadFraction:           data[21],
+		minPayloadFraction:           data[22],
+		leafPayloadFraction:          data[23],
+		fileChangeCounter:            data[24:28],
+		dbSizeInPages:                data[28:32],
+		pageNumberFirstFreeTrunkPage: data[32:36],
+		totalFreeListPages:           data[36:40],
+		schemaCookie:                 data[40:44],
+		schemaFormatNumber:           data[44:48],
+		defaultPageCacheSize:         data[48:52],
+		largestRootBtreeVacum:        data[52:56],
+		dbTextEncoding:               data[56:60],
+		userVersion:                  data[60:64],
+		incrementalVacuum:            data[64:68],
+		appId:                        data[68:72],
+		reservedExpansion:            data[72:92],
+		versionValidForNumber:        data[92:96],
+		sqlVersionNumber:             data[96:100],
+	}
+}
+
+func (h btreeHeader) isInterior() bool {
+	return h.btreeType == 0x05 || h.btreeType == 0x02
+}
+
+func (h btreeHeader) isIndex() bool {
+	return h.btreeType == 0x02 || h.btreeType == 0x0a
+}
+
+// size of the btree header, interior pages have extra 4 bytes for right most pointer
+func (h btreeHeader) size() int {
+	if h.isInterior() {
+		return 12
+	}
+	return 8
+}
+
+// pageSize returns page size in bytes, 65536 doesn't fit in two bytes so it is stored as 1
+func (h dbHeader) pageSize() int {
+	if h.dbSizeInBytes == 1 {
+		return 65536
+	}
+	return int(h.dbSizeInBytes)
+}
+
+// textEncoding returns encoding of all text values, database created before setting it has 0 which means utf-8
+func (h dbHeader) textEncoding() uint32 {
+	if len(h.dbTextEncoding) != 4 {
+		return utf8Encoding
+	}
+
+	encoding := binary.BigEndian.Uint32(h.dbTextEncoding)
+	if encoding == 0 {
+		return utf8Encoding
+	}
+	return encoding
+}
+
+func parseBtreeHeader(data []byte) btreeHeader {
+	btreeType := data[0]
+	header := btreeHeader{
+		btreeType:                    btreeType,
+		startOfFirstFreeblock:        data[1:3],
+		numberOfCells:                binary.BigEndian.Uint16(data[3:5]),
+		startOfCellContentArea:       binary.BigEndian.Uint16(data[5:7]),
+		numberOfFragmenetedFreeBytes: data[7],
+	}
+
+	if header.isInterior() {
+		header.rightMostPointer = data[8:12]
+	}
+
+	return header
+}
+
+// parseCell reads cell header and key, payload is only located so overflow chain is read and record is decoded
+// by cellRecord when the cell is actually used
+func (r fileReader) parseCell(data []byte, btreeType byte) (btreeCell, error) {
+	// "\x81\x02\x01\a\x17\x19\x19\x01\x81_tablebananabanana\x02CREATE TABLE banana (id integer primary key, apple text,banana text,raspberry text,pear text,orange text)"
+	// fmt.Println("data", data, len(data))
+
+	var pageNumberLeftChild []byte
+	if btreeType == 0x05 || btreeType == 0x02 {
+		if len(data) < 4 {
+			return btreeCell{}, corruptError("cell is too short for child pointer")
+		}
+		pageNumberLeftChild = data[:4]
+		data = data[4:]
+	}
+
+	var numberOfBytesPayload uint64
+	if btreeType != 0x05 {
+		// b := data[0]
+		numberOfBytesPayload, data = parseVarint(data)
+	}
+
+	// 00000010 10000001
+
+	var rowid int64
+	if btreeType == 0x0d || btreeType == 0x05 {
+		var rowidData uint64
+		rowidData, data = parseVarint(data)
+		rowid = int64(rowidData)
+	}
+	var payload []byte
+	var pageNumberOfFirstoverflow []byte
+	if btreeType != 0x05 {
+		localSize := r.localPayloadSize(int(numberOfBytesPayload), btreeType)
+		if localSize > len(data) {
+			return btreeCell{}, corruptError("cell payload of %v bytes doesn't fit in page", localSize)
+		}
+		payload = data[:localSize]
+		data = data[localSize:]
+
+		if localSize < int(numberOfBytesPayload) {
+			if len(data) < 4 {
+				return btreeCell{}, corruptError("cell is too short for overflow page pointer")
+			}
+			pageNumberOfFirstoverflow = data[:4]
+		}
+	}
+
+	return btreeCell{
+		pageNumberLeftChild:       pageNumberLeftChild,
+		rowId:                     rowid,
+		payloadSize:               int(numberOfBytesPayload),
+		localPayload:              payload,
+		pageNumberOfFirstoverflow: pageNumberOfFirstoverflow,
+	}, nil
+}
+
+// cellRecord returns decoded record of the cell, payload which doesn't fit in the page is read from overflow pages
+func (r fileReader) cellRecord(cell btreeCell) ([]any, error) {
+	payload := cell.localPayload
+	if cell.pageNumberOfFirstoverflow != nil {
+		var err error
+		payload, err = r.readOverflow(payload, cell.payloadSize, binary.BigEndian.Uint32(cell.pageNumberOfFirstoverflow))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	record, err := parseRecord(payload)
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeRecordText(record), nil
+}
+
+// text encodings stored in database header
+const (
+	utf8Encoding    uint32 = 1
+	utf16leEncoding uint32 = 2
+	utf16beEncoding uint32 = 3
+)
+
+// decodeRecordText transcodes text values to utf-8 when database uses utf-16 encoding
+func (r fileReader) decodeRecordText(record []any) []any {
+	encoding := r.header.textEncoding()
+	if encoding == utf8Encoding {
+		return record
+	}
+
+	for i, val := range record {
+		if text, ok := val.(string); ok {
+			record[i] = decodeText([]byte(text), encoding)
+		}
+	}
+
+	return record
+}
+
+func decodeText(data []byte, encoding uint32) string {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if encoding == utf16beEncoding {
+		byteOrder = binary.BigEndian
+	}
+
+	codeUnits := make([]uint16, len(data)/2)
+	for i := range codeUnits {
+		codeUnits[i] = byteOrder.Uint16(data[i*2 : i*2+2])
+	}
+
+	return string(utf16.Decode(codeUnits))
+}
+
+func encodeText(text string, encoding uint32) []byte {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if encoding == utf16beEncoding {
+		byteOrder = binary.BigEndian
+	}
+
+	codeUnits := utf16.Encode([]rune(text))
+	data := make([]byte, len(codeUnits)*2)
+	for i, codeUnit := range codeUnits {
+		byteOrder.PutUint16(data[i*2:], codeUnit)
+	}
+
+	return data
+}
+
+// localPayloadSize returns how many bytes of a payload are stored on the b-tree page itself,
+// the rest spills into the overflow chain. Formulas come from the sqlite file format docs.
+func (r fileReader) localPayloadSize(payloadSize int, btreeType byte) int {
+	usableSize := r.usableSize()
+
+	maxLocal := (usableSize-12)*int(r.header.maxPayloadFraction)/255 - 23
+	minLocal := (usableSize-12)*int(r.header.minPayloadFraction)/255 - 23
+	if btreeType == 0x0d {
+		maxLocal = usableSize - 35
+		minLocal = (usableSize-12)*int(r.header.leafPayloadFraction)/255 - 23
+	}
+
+	if payloadSize <= maxLocal {
+		return payloadSize
+	}
+
+	localSize := minLocal + (payloadSize-minLocal)%(usableSize-4)
+	if localSize > maxLocal {
+		return minLocal
+	}
+
+	return localSize
+}
+
+// readOverflow follows the overflow page chain and appends its content to the local part of the payload.
+// Every overflow page starts with 4 bytes pointing to the next page, 0 means it is the last one.
+func (r fileReader) readOverflow(payload []byte, payloadSize int, overflowPage uint32) ([]byte, error) {
+	fullPayload := make([]byte, len(payload), payloadSize)
+	copy(fullPayload, payload)
+
+	for overflowPage != 0 && len(fullPayload) < payloadSize {
+		page, err := r.read(int(overflowPage))
+		if err != nil {
+			return nil, err
+		}
+		overflowPage = binary.BigEndian.Uint32(page[:4])
+
+		content := page[4:r.usableSize()]
+		remaining := payloadSize - len(fullPayload)
+		if len(content) > remaining {
+			content = content[:remaining]
+		}
+		fullPayload = append(fullPayload, content...)
+	}
+
+	if len(fullPayload) < payloadSize {
+		return nil, corruptError("overflow chain ended after %v of %v bytes", len(fullPayload), payloadSize)
+	}
+
+	return fullPayload, nil
+}
+
+func parseRecord(data []byte) ([]any, error) {
+	recordSize := len(data)
+	var headerSize uint64
+	headerSize, data = parseVarint(data)
+
+	// header size includes the varint holding it
+	headerVarintSize := uint64(recordSize - len(data))
+	if headerSize < headerVarintSize || headerSize > uint64(recordSize) {
+		return nil, corruptError("record header size %v out of bounds", headerSize)
+	}
+
+	columns := data[:headerSize-headerVarintSize]
+	data = data[headerSize-headerVarintSize:]
+	res := []uint64{}
+	for len(columns) > 0 {
+		var data uint64
+		data, columns = parseVarint(columns)
+		res = append(res, data)
+	}
+
+	resData := []any{}
+
+	for _, column := range res {
+		size := serialTypeSize(column)
+		if size > len(data) {
+			return nil, corruptError("record value of serial type %v exceeds record size", column)
+		}
+
+		switch column {
+		case 0:
+			resData = append(resData, nil)
+		case 1, 2, 3, 4, 5, 6:
+			val := bigEndianSigned(data[:size])
+			data = data[size:]
+			resData = append(resData, val)
+		case 7:
+			val := math.Float64frombits(binary.BigEndian.Uint64(data[:8]))
+			data = data[8:]
+			resData = append(resData, val)
+		case 8:
+			resData = append(resData, int64(0))
+		case 9:
+			resData = append(resData, int64(1))
+		case 10, 11:
+			return nil, corruptError("reserved serial type %v", column)
+		default:
+			// even serial types are blobs, odd ones are text
+			if column%2 == 0 {
+				blob := data[:size]
+				data = data[size:]
+				resData = append(resData, blob)
+				continue
+			}
+			text := string(data[:size])
+			data = data[size:]
+			resData = append(resData, text)
+		}
+	}
+
+	return resData, nil
+
+}
+
+// encodeRecord serializes values in record format, it is the inverse of parseRecord
+func encodeRecord(values []any) []byte {
+	header := []byte{}
+	body := []byte{}
+	for _, val := range values {
+		switch v := val.(type) {
+		case nil:
+			header = appendVarint(header, 0)
+		case int64:
+			serialType := integerSerialType(v)
+			header = appendVarint(header, serialType)
+			size := serialTypeSize(serialType)
+			for i := size - 1; i >= 0; i-- {
+				body = append(body, byte(v>>(8*i)))
+			}
+		case float64:
+			header = appendVarint(header, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			header = appendVarint(header, uint64(13+2*len(v)))
+			body = append(body, v...)
+		case []byte:
+			header = appendVarint(header, uint64(12+2*len(v)))
+			body = append(body, v...)
+		}
+	}
+
+	// header size includes the varint holding it, adding the varint can make it one byte longer
+	headerSize := len(header) + 1
+	for len(header)+len(appendVarint(nil, uint64(headerSize))) != headerSize {
+		headerSize = len(header) + len(appendVarint(nil, uint64(headerSize)))
+	}
+
+	record := appendVarint(nil, uint64(headerSize))
+	record = append(record, header...)
+	return append(record, body...)
+}
+
+// integerSerialType returns serial type of the smallest integer representation of the value
+func integerSerialType(val int64) uint64 {
+	switch {
+	case val == 0:
+		return 8
+	case val == 1:
+		return 9
+	case val >= math.MinInt8 && val <= math.MaxInt8:
+		return 1
+	case val >= math.MinInt16 && val <= math.MaxInt16:
+		return 2
+	case val >= -1<<23 && val < 1<<23:
+		return 3
+	case val >= math.MinInt32 && val <= math.MaxInt32:
+		return 4
+	case val >= -1<<47 && val < 1<<47:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// serialTypeSize returns number of bytes used by value of the serial type in record body
+func serialTypeSize(serialType uint64) int {
+	switch {
+	case serialType >= 1 && serialType <= 6:
+		return integerSerialTypeSizes[serialType]
+	case serialType == 7:
+		return 8
+	case serialType >= 12:
+		return int((serialType - 12) / 2)
+	default:
+		return 0
+	}
+}
+
+// number of bytes used by integer serial types 1-6, all are big-endian two's-complement
+var integerSerialTypeSizes = map[uint64]int{
+	1: 1,
+	2: 2,
+	3: 3,
+	4: 4,
+	5: 6,
+	6: 8,
+}
+
+type btreePage struct {
+	btreeHeader btreeHeader
+	cells       []btreeCell
+}
+
+// childPointer returns page number of i-th child of interior page, the last child is the right most pointer
+func (p btreePage) childPointer(i int) []byte {
+	if i == len(p.cells) {
+		return p.btreeHeader.rightMostPointer
+	}
+	return p.cells[i].pageNumberLeftChild
+}
+
+func (r fileReader) parsePage(page []byte, pageNumber int) (btreePage, error) {
+	// first page starts with the database header
+	headerOffset := 0
+	if pageNumber <= 1 {
+		headerOffset = 100
+	}
+
+	btreeHeader := parseBtreeHeader(page[headerOffset : headerOffset+12])
+	switch btreeHeader.btreeType {
+	case 0x02, 0x05, 0x0a, 0x0d:
+	default:
+		return btreePage{}, corruptError("page %v has invalid btree type %v", pageNumber, btreeHeader.btreeType)
+	}
+
+	// cell pointer array follows the btree header, it is sorted by key unlike the cell content area
+	cellPointers := page[headerOffset+btreeHeader.size():]
+	if int(btreeHeader.numberOfCells)*2 > len(cellPointers) {
+		return btreePage{}, corruptError("page %v has too many cells: %v", pageNumber, btreeHeader.numberOfCells)
+	}
+
+	cells := []btreeCell{}
+	for i := 0; i < int(btreeHeader.numberOfCells); i++ {
+		cellOffset := int(binary.BigEndian.Uint16(cellPointers[i*2 : i*2+2]))
+		if cellOffset >= len(page) {
+			return btreePage{}, corruptError("page %v cell offset %v out of bounds", pageNumber, cellOffset)
+		}
+
+		cell, err := r.parseCell(page[cellOffset:], btreeHeader.btreeType)
+		if err != nil {
+			return btreePage{}, err
+		}
+
+		cells = append(cells, cell)
+	}
+
+	return btreePage{
+		btreeHeader: btreeHeader,
+		cells:       cells,
+	}, nil
+
+}
+
+type dbSchema struct {
+	schemaType string
+	schemaName string
+	tableName  string
+	rootPage   int64
+	sqlText    string
+}
+
+func (r fileReader) parseDataBaseSchemas(page btreePage) ([]dbSchema, error) {
+	schemas := []dbSchema{}
+
+	for i := 0; i < len(page.cells); i++ {
+		record, err := r.cellRecord(page.cells[i])
+		if err != nil {
+			return nil, err
+		}
+		dbSchema, err := parseDataBaseSchema(record)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, dbSchema)
+	}
+
+	return schemas, nil
+}
+
+func parseDataBaseSchema(record []any) (dbSchema, error) {
+
+	if len(record) != 5 {
+		return dbSchema{}, corruptError("schema record should contain 5 fields, got: %v", len(record))
+	}
+	schemaType, ok := record[0].(string)
+	if !ok {
+		return dbSchema{}, corruptError("schema type should be string")
+	}
+
+	schemaName, ok := record[1].(string)
+	if !ok {
+		return dbSchema{}, corruptError("schema name should be string")
+	}
+
+	tableName, ok := record[2].(string)
+	if !ok {
+		return dbSchema{}, corruptError("table name should be string")
+	}
+
+	// views and triggers have root page 0 stored as null
+	rootPage, ok := record[3].(int64)
+	if !ok && record[3] != nil {
+		return dbSchema{}, corruptError("root page not a number")
+	}
+
+	sqlText, _ := record[4].(string)
+
+	return dbSchema{
+		schemaType: schemaType,
+		schemaName: schemaName,
+		tableName:  tableName,
+		rootPage:   rootPage,
+		sqlText:    sqlText,
+	}, nil
+}
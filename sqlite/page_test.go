@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalPayloadSize(t *testing.T) {
+	reader := fileReader{
+		pageSize: 4096,
+		header: dbHeader{
+			maxPayloadFraction:  64,
+			minPayloadFraction:  32,
+			leafPayloadFraction: 32,
+		},
+	}
+
+	if size := reader.localPayloadSize(100, 0x0d); size != 100 {
+		t.Errorf("expected small payload to be stored locally, got: %v", size)
+	}
+
+	if size := reader.localPayloadSize(4061, 0x0d); size != 4061 {
+		t.Errorf("expected payload equal to max local to fit in the page, got: %v", size)
+	}
+
+	// M = (4084*32/255)-23 = 489, K = M + (P-M)%(U-4) = 489 + 8511%4092 = 816
+	if size := reader.localPayloadSize(9000, 0x0d); size != 816 {
+		t.Errorf("expected local size of table leaf payload to be 816, got: %v", size)
+	}
+
+	// index pages have smaller max local, X = (4084*64/255)-23 = 1002
+	if size := reader.localPayloadSize(1002, 0x0a); size != 1002 {
+		t.Errorf("expected index payload equal to max local to fit in the page, got: %v", size)
+	}
+
+	if size := reader.localPayloadSize(1003, 0x0a); size != 489 {
+		t.Errorf("expected index payload to spill into overflow, got: %v", size)
+	}
+}
+
+func TestParseIndexLeafPage(t *testing.T) {
+	reader := fileReader{pageSize: 4096, header: dbHeader{maxPayloadFraction: 64, minPayloadFraction: 32, leafPayloadFraction: 32}}
+
+	page := make([]byte, 4096)
+	// index leaf header with 2 cells, content area starts at 4000
+	copy(page, []byte{0x0a, 0, 0, 0, 2, 0x0f, 0xa0, 0})
+	// cell pointers are in key order, content area is not
+	copy(page[8:], []byte{0x0f, 0xd2, 0x0f, 0xa0})
+	// record: header size 3, text of length 1, 8-bit int; key followed by rowid
+	copy(page[4000:], []byte{5, 3, 15, 1, 'b', 2})
+	copy(page[4050:], []byte{5, 3, 15, 1, 'a', 5})
+
+	parsedPage, err := reader.parsePage(page, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parsedPage.btreeHeader.isIndex() || parsedPage.btreeHeader.isInterior() {
+		t.Errorf("expected page to be index leaf, got type: %v", parsedPage.btreeHeader.btreeType)
+	}
+
+	if len(parsedPage.cells) != 2 {
+		t.Fatalf("expected 2 cells, got: %v", len(parsedPage.cells))
+	}
+
+	keys := []any{}
+	for _, cell := range parsedPage.cells {
+		record, err := reader.cellRecord(cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, record[0])
+	}
+	if !reflect.DeepEqual(keys, []any{"a", "b"}) {
+		t.Errorf("expected cells to be in key order, got: %v", keys)
+	}
+}
+
+// buildOverflowDatabase writes database with table t (a integer, b text) of 3 rows in one leaf,
+// text of the second row is stored in two overflow pages
+func buildOverflowDatabase(t *testing.T, text string) string {
+	t.Helper()
+
+	schema := buildPage(1, 0x0d, [][]byte{
+		tableLeafCell(1, "table", "t", "t", int64(2), "CREATE TABLE t (a integer, b text)"),
+	}, 0)
+
+	reader := fileReader{pageSize: testPageSize, header: parseDatabaseHeader(testDatabaseHeader())}
+	record := encodeRecord([]any{nil, text})
+	localSize := reader.localPayloadSize(len(record), 0x0d)
+	overflowCell := appendVarint(nil, uint64(len(record)))
+	overflowCell = appendVarint(overflowCell, 2)
+	overflowCell = append(overflowCell, record[:localSize]...)
+	overflowCell = binary.BigEndian.AppendUint32(overflowCell, 3)
+
+	overflowPages := [][]byte{make([]byte, testPageSize), make([]byte, testPageSize)}
+	binary.BigEndian.PutUint32(overflowPages[0], 4)
+	rest := record[localSize:]
+	rest = rest[copy(overflowPages[0][4:], rest):]
+	if copy(overflowPages[1][4:], rest) != len(rest) {
+		t.Fatalf("record of %v bytes doesn't fit in two overflow pages", len(record))
+	}
+
+	pages := [][]byte{
+		schema,
+		buildPage(2, 0x0d, [][]byte{tableLeafCell(1, nil, "first"), overflowCell, tableLeafCell(3, nil, "third")}, 0),
+		overflowPages[0],
+		overflowPages[1],
+	}
+
+	return writeTestDatabase(t, pages)
+}
+
+func TestReadOverflowRow(t *testing.T) {
+	text := strings.Repeat("overflow ", 120)
+	db, err := Open(buildOverflowDatabase(t, text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	queryValues := func(query string) []any {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values := []any{}
+		for rows.Next() {
+			values = append(values, rows.Values()[0])
+		}
+		if rows.Err() != nil {
+			t.Fatal(rows.Err())
+		}
+		return values
+	}
+
+	// overflow chain of neighbouring row is read only when that row is returned
+	if values := queryValues("SELECT b FROM t WHERE rowid = 3"); !reflect.DeepEqual(values, []any{"third"}) {
+		t.Errorf("expected third row, got: %v", values)
+	}
+	for _, pageNumber := range []int{3, 4} {
+		if _, ok := db.reader.cache.get(pageNumber); ok {
+			t.Errorf("expected overflow page %v not to be read", pageNumber)
+		}
+	}
+
+	if values := queryValues("SELECT b FROM t WHERE rowid = 2"); !reflect.DeepEqual(values, []any{text}) {
+		t.Errorf("expected text of %v bytes, got: %v", len(text), values)
+	}
+	if values := queryValues("SELECT b FROM t"); !reflect.DeepEqual(values, []any{"first", text, "third"}) {
+		t.Errorf("expected all rows, got: %v", values)
+	}
+}
+
+func TestParseRecordSignedIntegersAndReal(t *testing.T) {
+	record, err := parseRecord([]byte{
+		// header size and serial types: 8-bit, 16-bit, 24-bit, 48-bit, 64-bit int, float, zero, one
+		9, 1, 2, 3, 5, 6, 7, 8, 9,
+		0xff,
+		0x80, 0x00,
+		0x00, 0x01, 0x00,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+		0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []any{int64(-1), int64(-32768), int64(256), int64(-2), int64(math.MaxInt64), math.Pi, int64(0), int64(1)}
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("expected record to be %v, got: %v", expected, record)
+	}
+}
+
+func TestParseRecordBlobAndText(t *testing.T) {
+	// serial type 16 is blob of 2 bytes, 17 is text of 2 bytes
+	record, err := parseRecord([]byte{3, 16, 17, 0xca, 0xfe, 'h', 'i'})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob, ok := record[0].([]byte)
+	if !ok || !reflect.DeepEqual(blob, []byte{0xca, 0xfe}) {
+		t.Errorf("expected first value to be blob, got: %#v", record[0])
+	}
+
+	if record[1] != "hi" {
+		t.Errorf("expected second value to be text, got: %#v", record[1])
+	}
+}
+
+func TestEncodeRecord(t *testing.T) {
+	values := []any{
+		nil, int64(0), int64(1), int64(-128), int64(300), int64(-1 << 23), int64(1 << 40), int64(math.MinInt64),
+		2.5, "", "hello", []byte{}, []byte{0xca, 0xfe}, string(make([]byte, 200)),
+	}
+
+	record, err := parseRecord(encodeRecord(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(record, values) {
+		t.Errorf("expected encoded record to be parsed back to %#v, got: %#v", values, record)
+	}
+
+	// header with more than 127 bytes needs two bytes for its size
+	manyValues := make([]any, 200)
+	record, err = parseRecord(encodeRecord(manyValues))
+	if err != nil || len(record) != 200 {
+		t.Errorf("expected record with 200 nulls, got: %v values, err: %v", len(record), err)
+	}
+}
+
+func TestDecodeUtf16Text(t *testing.T) {
+	if text := decodeText([]byte{'Z', 0, 0xf3, 0, 0x42, 0x01, 0x3d, 0xd8, 0x00, 0xde}, utf16leEncoding); text != "Zół😀" {
+		t.Errorf("expected utf-16le text to be decoded, got: %v", text)
+	}
+
+	if text := decodeText([]byte{0, 'Z', 0, 0xf3, 0x01, 0x42, 0xd8, 0x3d, 0xde, 0x00}, utf16beEncoding); text != "Zół😀" {
+		t.Errorf("expected utf-16be text to be decoded, got: %v", text)
+	}
+
+	if text := decodeText(encodeText("Zół😀", utf16leEncoding), utf16leEncoding); text != "Zół😀" {
+		t.Errorf("expected encoded text to be decoded back, got: %v", text)
+	}
+}
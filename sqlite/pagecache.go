@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"container/list"
+	"sync"
+)
+
+// used when database header doesn't suggest cache size, the same as sqlite default of 2000 KiB
+const defaultCacheSizeKiB = 2000
+
+// pageCache keeps most recently used pages, least recently used page is evicted when limit is reached
+type pageCache struct {
+	mu     sync.Mutex
+	limit  int
+	pages  map[int]*list.Element
+	lru    *list.List
+	hits   int
+	misses int
+}
+
+type cachedPage struct {
+	pageNumber int
+	data       []byte
+}
+
+func newPageCache(limit int) *pageCache {
+	return &pageCache{
+		limit: max(limit, 1),
+		pages: make(map[int]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+func (c *pageCache) get(pageNumber int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.pages[pageNumber]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.lru.MoveToFront(element)
+	return element.Value.(cachedPage).data, true
+}
+
+func (c *pageCache) put(pageNumber int, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.pages[pageNumber]; ok {
+		element.Value = cachedPage{pageNumber: pageNumber, data: data}
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.pages[pageNumber] = c.lru.PushFront(cachedPage{pageNumber: pageNumber, data: data})
+
+	if c.lru.Len() > c.limit {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.pages, oldest.Value.(cachedPage).pageNumber)
+	}
+}
+
+func (c *pageCache) stats() (hits int, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
+
+// cacheSizeInPages converts suggested cache size from header, positive value is number of pages
+// and negative one is size in KiB
+func cacheSizeInPages(suggestedCacheSize int32, pageSize int) int {
+	if suggestedCacheSize > 0 {
+		return int(suggestedCacheSize)
+	}
+
+	sizeKiB := defaultCacheSizeKiB
+	if suggestedCacheSize < 0 {
+		sizeKiB = int(-suggestedCacheSize)
+	}
+
+	return sizeKiB * 1024 / pageSize
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import "testing"
+
+func TestPageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newPageCache(2)
+
+	cache.put(1, []byte{1})
+	cache.put(2, []byte{2})
+	cache.get(1)
+	cache.put(3, []byte{3})
+
+	if _, ok := cache.get(2); ok {
+		t.Errorf("expected least recently used page to be evicted")
+	}
+
+	if page, ok := cache.get(1); !ok || page[0] != 1 {
+		t.Errorf("expected recently used page to stay in cache")
+	}
+
+	if hits, misses := cache.stats(); hits != 2 || misses != 1 {
+		t.Errorf("expected 2 hits and 1 miss, got: %v hits, %v misses", hits, misses)
+	}
+}
+
+func TestCacheSizeInPages(t *testing.T) {
+	if size := cacheSizeInPages(100, 4096); size != 100 {
+		t.Errorf("expected positive cache size to be number of pages, got: %v", size)
+	}
+
+	if size := cacheSizeInPages(-2000, 4096); size != 500 {
+		t.Errorf("expected negative cache size to be size in KiB, got: %v", size)
+	}
+
+	if size := cacheSizeInPages(0, 1024); size != 2000 {
+		t.Errorf("expected default cache size to be 2000 KiB, got: %v", size)
+	}
+}
@@ -0,0 +1,837 @@
+package sqlite
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSelectStatementWithCountStarAggregate(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT COUNT(*) FROM apples")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	if statement.from != (tableRef{name: "apples"}) {
+		t.Errorf("Expect from tables to be apples, got: %v", statement.from)
+	}
+
+	if len(statement.columns) != 1 {
+		t.Fatalf("Expect to find only 1 field instead we got: %v", len(statement.columns))
+	}
+
+	expected := resultColumn{expr: functionCallExpr{name: "count", args: []expression{}, star: true}, name: "COUNT(*)"}
+	if !reflect.DeepEqual(statement.columns[0], expected) {
+		t.Errorf("Expect column to be %+v got: %+v", expected, statement.columns[0])
+	}
+}
+
+func TestSelectStatementWithField(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT aa, bb FROM apples")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	if statement.from != (tableRef{name: "apples"}) {
+		t.Errorf("Expect from tables to be apples, got: %v", statement.from)
+	}
+
+	expected := []resultColumn{
+		{expr: columnRefExpr{name: "aa"}, name: "aa"},
+		{expr: columnRefExpr{name: "bb"}, name: "bb"},
+	}
+	if !reflect.DeepEqual(statement.columns, expected) {
+		t.Errorf("Expect columns to be %+v got: %+v", expected, statement.columns)
+	}
+
+	if statement.where != nil {
+		t.Errorf("Expect where to be empty got: %+v", statement.where)
+	}
+}
+
+func TestSelectStatementWithFieldAndAggregate(t *testing.T) {
+	// column outside of aggregate is rejected by planner, parser accepts it
+	ast, err := parseSqlStatement("SELECT aa, count(*) FROM apples")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	expected := []resultColumn{
+		{expr: columnRefExpr{name: "aa"}, name: "aa"},
+		{expr: functionCallExpr{name: "count", args: []expression{}, star: true}, name: "count(*)"},
+	}
+	if !reflect.DeepEqual(statement.columns, expected) {
+		t.Errorf("Expect columns to be %+v got: %+v", expected, statement.columns)
+	}
+}
+
+func TestSelectStatementWithExpressions(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT -price * (1 + tax), name || ' ' || color FROM apples")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := ast.(selectStatement)
+
+	expected := []resultColumn{
+		{
+			expr: binaryExpr{
+				operator: "*",
+				left:     unaryExpr{operator: "-", operand: columnRefExpr{name: "price"}},
+				right:    binaryExpr{operator: "+", left: literalExpr{value: int64(1)}, right: columnRefExpr{name: "tax"}},
+			},
+			name: "-price * (1 + tax)",
+		},
+		{
+			expr: binaryExpr{
+				operator: "||",
+				left:     binaryExpr{operator: "||", left: columnRefExpr{name: "name"}, right: literalExpr{value: " "}},
+				right:    columnRefExpr{name: "color"},
+			},
+			name: "name || ' ' || color",
+		},
+	}
+	if !reflect.DeepEqual(statement.columns, expected) {
+		t.Errorf("Expect columns to be %+v got: %+v", expected, statement.columns)
+	}
+}
+
+func TestExpressionPrecedence(t *testing.T) {
+	a, b, c := columnRefExpr{name: "a"}, columnRefExpr{name: "b"}, columnRefExpr{name: "c"}
+	one, two, three := literalExpr{value: int64(1)}, literalExpr{value: int64(2)}, literalExpr{value: int64(3)}
+
+	testCases := []struct {
+		where    string
+		expected expression
+	}{
+		{
+			where:    "a OR b AND c",
+			expected: binaryExpr{operator: "OR", left: a, right: binaryExpr{operator: "AND", left: b, right: c}},
+		},
+		{
+			where:    "(a OR b) AND c",
+			expected: binaryExpr{operator: "AND", left: binaryExpr{operator: "OR", left: a, right: b}, right: c},
+		},
+		{
+			where:    "NOT a = 1 AND b",
+			expected: binaryExpr{operator: "AND", left: unaryExpr{operator: "NOT", operand: binaryExpr{operator: "=", left: a, right: one}}, right: b},
+		},
+		{
+			where:    "a + 1 * 2 > 3",
+			expected: binaryExpr{operator: ">", left: binaryExpr{operator: "+", left: a, right: binaryExpr{operator: "*", left: one, right: two}}, right: three},
+		},
+		{
+			where:    "a < 1 = b <> 2",
+			expected: binaryExpr{operator: "!=", left: binaryExpr{operator: "=", left: binaryExpr{operator: "<", left: a, right: one}, right: b}, right: two},
+		},
+		{
+			where:    "a == 1 OR b != 2",
+			expected: binaryExpr{operator: "OR", left: binaryExpr{operator: "=", left: a, right: one}, right: binaryExpr{operator: "!=", left: b, right: two}},
+		},
+		{
+			where:    "1 || 2 * 3",
+			expected: binaryExpr{operator: "*", left: binaryExpr{operator: "||", left: one, right: two}, right: three},
+		},
+		{
+			where:    "a - - 1 % 2",
+			expected: binaryExpr{operator: "-", left: a, right: binaryExpr{operator: "%", left: unaryExpr{operator: "-", operand: one}, right: two}},
+		},
+		{
+			where:    "a NOT BETWEEN 1 AND 2 AND b IS NULL",
+			expected: binaryExpr{operator: "AND", left: betweenExpr{expr: a, low: one, high: two, not: true}, right: binaryExpr{operator: "IS", left: b, right: literalExpr{}}},
+		},
+		{
+			where:    "NOT a IS NOT NULL = 1",
+			expected: unaryExpr{operator: "NOT", operand: binaryExpr{operator: "=", left: binaryExpr{operator: "IS NOT", left: a, right: literalExpr{}}, right: one}},
+		},
+		{
+			where:    "a IS NOT",
+			expected: nil,
+		},
+		{
+			where:    "a NOT BETWEEN 1 + 1 AND 3 AND b",
+			expected: binaryExpr{operator: "AND", left: betweenExpr{expr: a, low: binaryExpr{operator: "+", left: one, right: one}, high: three, not: true}, right: b},
+		},
+	}
+
+	for _, testCase := range testCases {
+		ast, err := parseSqlStatement("SELECT a FROM t WHERE " + testCase.where)
+		if testCase.expected == nil {
+			if err == nil {
+				t.Errorf("Expected %q to fail", testCase.where)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %q to be parsed, got: %v", testCase.where, err)
+			continue
+		}
+
+		where := ast.(selectStatement).where
+		if !reflect.DeepEqual(where, testCase.expected) {
+			t.Errorf("Expected %q to be parsed as %+v, got: %+v", testCase.where, testCase.expected, where)
+		}
+	}
+}
+
+func TestCreateTableStatement(t *testing.T) {
+	ast, err := parseSqlStatement(`CREATE TABLE apples
+(
+        id integer primary key autoincrement,
+        name text,
+        color text
+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTableStatement, ok := ast.(createTableStatement)
+
+	if !ok {
+		t.Errorf("Exepected type create table statement")
+	}
+
+	if createTableStatement.tableName != "apples" {
+		t.Errorf("Expect table name to be apples, got: %v", createTableStatement.tableName)
+	}
+
+	if len(createTableStatement.columns) != 3 {
+		t.Errorf("expect 3 columns got :%v", len(createTableStatement.columns))
+	}
+
+	if createTableStatement.columns[0].columnType != "integer" {
+		t.Errorf("Exepect first column to be integer type got: %v", createTableStatement.columns[0].columnType)
+	}
+
+	if createTableStatement.columns[0].name != "id" {
+		t.Errorf("Exepect first column to be name id: %v", createTableStatement.columns[0].name)
+	}
+
+	if !reflect.DeepEqual(createTableStatement.columns[0].constrains, []constrain{primaryKey, autoIncrement}) {
+		t.Errorf("expect first column to have constrain primarykey and autoincrement, got: %+v", createTableStatement.columns[0].constrains)
+	}
+
+	if createTableStatement.columns[1].columnType != "text" {
+		t.Errorf("Exepect second column to be text type got: %v", createTableStatement.columns[1].columnType)
+	}
+
+	if createTableStatement.columns[1].name != "name" {
+		t.Errorf("Exepect second column to be name name: %v", createTableStatement.columns[1].name)
+	}
+
+	if len(createTableStatement.columns[1].constrains) != 0 {
+		t.Errorf("Exepect second column to have 0 constrains: %v", len(createTableStatement.columns[1].constrains))
+	}
+
+	if createTableStatement.columns[2].columnType != "text" {
+		t.Errorf("Exepect second column to be text type got: %v", createTableStatement.columns[2].columnType)
+	}
+
+	if createTableStatement.columns[2].name != "color" {
+		t.Errorf("Exepect second column to be color name: %v", createTableStatement.columns[2].name)
+	}
+
+	if len(createTableStatement.columns[2].constrains) != 0 {
+		t.Errorf("Exepect second column to have 0 constrains: %v", len(createTableStatement.columns[2].constrains))
+	}
+}
+
+func TestCreateTableStatementWithConstraints(t *testing.T) {
+	ast, err := parseSqlStatement(`CREATE TABLE IF NOT EXISTS "orders" (
+	id INTEGER,
+	customer_id INTEGER NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+	note VARCHAR(255) DEFAULT 'none' CHECK (length(note) > 0),
+	total DOUBLE PRECISION,
+	CONSTRAINT pk_orders PRIMARY KEY (id),
+	UNIQUE (customer_id, note)
+)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTableStatement := ast.(createTableStatement)
+
+	if createTableStatement.tableName != "orders" {
+		t.Errorf("Expect table name to be orders, got: %v", createTableStatement.tableName)
+	}
+
+	expected := []createTableColumn{
+		{name: "id", columnType: "INTEGER", constrains: []constrain{primaryKey}},
+		{name: "customer_id", columnType: "INTEGER", constrains: []constrain{notNull}},
+		{name: "note", columnType: "VARCHAR(255)", constrains: []constrain{}},
+		{name: "total", columnType: "DOUBLE PRECISION", constrains: []constrain{}},
+	}
+	if !reflect.DeepEqual(createTableStatement.columns, expected) {
+		t.Errorf("Expect columns to be %+v, got: %+v", expected, createTableStatement.columns)
+	}
+
+	if !createTableStatement.columns[0].isRowidAlias() {
+		t.Errorf("Expect table primary key to make id alias of rowid")
+	}
+}
+
+func TestSelectStatementWithWhereClause(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT aa FROM apples where aa='test1234'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	if statement.from != (tableRef{name: "apples"}) {
+		t.Errorf("Expect from tables to be apples, got: %v", statement.from)
+	}
+
+	expected := binaryExpr{operator: "=", left: columnRefExpr{name: "aa"}, right: literalExpr{value: "test1234"}}
+	if !reflect.DeepEqual(statement.where, expected) {
+		t.Errorf("Expect where to be %+v, got: %+v", expected, statement.where)
+	}
+}
+
+func TestSelectStatementWithMultipleWhereClause(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT aa, bb FROM apples where aa='test1234' AND bb='1234test'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	expected := binaryExpr{
+		operator: "AND",
+		left:     binaryExpr{operator: "=", left: columnRefExpr{name: "aa"}, right: literalExpr{value: "test1234"}},
+		right:    binaryExpr{operator: "=", left: columnRefExpr{name: "bb"}, right: literalExpr{value: "1234test"}},
+	}
+	if !reflect.DeepEqual(statement.where, expected) {
+		t.Errorf("Expect where to be %+v, got: %+v", expected, statement.where)
+	}
+}
+
+func TestCreateIndexStatement(t *testing.T) {
+	ast, err := parseSqlStatement("CREATE INDEX idx_companies_country on companies (country)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createIndexStatement, ok := ast.(createIndexStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type create index statement, got: %v", reflect.TypeOf(ast))
+	}
+
+	if createIndexStatement.indexName != "idx_companies_country" {
+		t.Errorf("Expect index name to be idx_companies_country, got: %v", createIndexStatement.indexName)
+	}
+
+	if createIndexStatement.tableName != "companies" {
+		t.Errorf("Expect table name to be companies, got: %v", createIndexStatement.tableName)
+	}
+
+	if !reflect.DeepEqual(createIndexStatement.columns, []indexedColumn{{name: "country"}}) {
+		t.Errorf("Expect indexed columns to be country, got: %+v", createIndexStatement.columns)
+	}
+}
+
+func TestCreateUniqueIndexStatementWithMultipleColumns(t *testing.T) {
+	ast, err := parseSqlStatement("CREATE UNIQUE INDEX IF NOT EXISTS idx_name ON people (last_name, first_name DESC)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createIndexStatement, ok := ast.(createIndexStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type create index statement, got: %v", reflect.TypeOf(ast))
+	}
+
+	if !createIndexStatement.unique {
+		t.Errorf("Expect index to be unique")
+	}
+
+	if createIndexStatement.indexName != "idx_name" {
+		t.Errorf("Expect index name to be idx_name, got: %v", createIndexStatement.indexName)
+	}
+
+	expectedColumns := []indexedColumn{{name: "last_name"}, {name: "first_name", desc: true}}
+	if !reflect.DeepEqual(createIndexStatement.columns, expectedColumns) {
+		t.Errorf("Expect indexed columns to be %+v, got: %+v", expectedColumns, createIndexStatement.columns)
+	}
+}
+
+func TestSelectStatementWithBetweenCondition(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT id FROM superheroes WHERE id BETWEEN 10 AND 20 AND name >= 'a'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	expected := binaryExpr{
+		operator: "AND",
+		left:     betweenExpr{expr: columnRefExpr{name: "id"}, low: literalExpr{value: int64(10)}, high: literalExpr{value: int64(20)}},
+		right:    binaryExpr{operator: ">=", left: columnRefExpr{name: "name"}, right: literalExpr{value: "a"}},
+	}
+
+	if !reflect.DeepEqual(statement.where, expected) {
+		t.Errorf("Expected conditions to be %+v, got: %+v", expected, statement.where)
+	}
+}
+
+func TestSelectStatementWithBlobLiteral(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT id FROM files WHERE checksum = X'CAFE'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement, ok := ast.(selectStatement)
+
+	if !ok {
+		t.Fatalf("Exepected type to be select statement")
+	}
+
+	expected := binaryExpr{operator: "=", left: columnRefExpr{name: "checksum"}, right: literalExpr{value: []byte{0xca, 0xfe}}}
+	if !reflect.DeepEqual(statement.where, expected) {
+		t.Errorf("Expected comparison value to be blob, got: %#v", statement.where)
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	testCases := []struct {
+		literal  string
+		expected any
+	}{
+		{literal: "42", expected: int64(42)},
+		{literal: "1.5", expected: 1.5},
+		{literal: ".5", expected: 0.5},
+		{literal: "5.", expected: 5.0},
+		{literal: "1e3", expected: 1000.0},
+		{literal: "25E-2", expected: 0.25},
+		{literal: "0x1F", expected: int64(31)},
+		{literal: "0xFFFFFFFFFFFFFFFF", expected: int64(-1)},
+		{literal: "9223372036854775808", expected: 9223372036854775808.0},
+		{literal: "-9223372036854775808", expected: int64(math.MinInt64)},
+	}
+
+	for _, testCase := range testCases {
+		ast, err := parseSqlStatement("SELECT " + testCase.literal + " FROM t")
+		if err != nil {
+			t.Errorf("Expected %q to be parsed, got: %v", testCase.literal, err)
+			continue
+		}
+
+		expected := literalExpr{value: testCase.expected}
+		if expr := ast.(selectStatement).columns[0].expr; !reflect.DeepEqual(expr, expected) {
+			t.Errorf("Expected %q to be parsed as %#v, got: %#v", testCase.literal, expected, expr)
+		}
+	}
+
+	for _, literal := range []string{"12abc", "1e", "1e+", "0x", "0xG1", "0x10000000000000000", "."} {
+		_, err := parseSqlStatement("SELECT " + literal + " FROM t")
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Expected %q to be syntax error, got: %v", literal, err)
+		}
+	}
+}
+
+func TestStringLiteralsAndIdentifiers(t *testing.T) {
+	ast, err := parseSqlStatement(`SELECT "first ""name""", [select], ` + "`it's`" + `, prénom -- the rest is ignored
+		FROM "my table" /* comment, with 'quotes' */
+		WHERE note = 'it''s (done); -- not a comment' OR data = x'00fF' OR "NULL" = 'Zoë'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := ast.(selectStatement)
+
+	if statement.from != (tableRef{name: "my table"}) {
+		t.Errorf("Expect from table to be my table, got: %v", statement.from)
+	}
+
+	columns := []expression{}
+	for _, column := range statement.columns {
+		columns = append(columns, column.expr)
+	}
+	expectedColumns := []expression{
+		columnRefExpr{name: `first "name"`},
+		columnRefExpr{name: "select"},
+		columnRefExpr{name: "it's"},
+		columnRefExpr{name: "prénom"},
+	}
+	if !reflect.DeepEqual(columns, expectedColumns) {
+		t.Errorf("Expect columns to be %+v, got: %+v", expectedColumns, columns)
+	}
+
+	expectedWhere := binaryExpr{
+		operator: "OR",
+		left: binaryExpr{
+			operator: "OR",
+			left:     binaryExpr{operator: "=", left: columnRefExpr{name: "note"}, right: literalExpr{value: "it's (done); -- not a comment"}},
+			right:    binaryExpr{operator: "=", left: columnRefExpr{name: "data"}, right: literalExpr{value: []byte{0x00, 0xff}}},
+		},
+		right: binaryExpr{operator: "=", left: columnRefExpr{name: "NULL"}, right: literalExpr{value: "Zoë"}},
+	}
+	if !reflect.DeepEqual(statement.where, expectedWhere) {
+		t.Errorf("Expect where to be %+v, got: %+v", expectedWhere, statement.where)
+	}
+}
+
+func TestUnterminatedTokens(t *testing.T) {
+	for _, query := range []string{
+		"SELECT 'abc FROM t",
+		"SELECT 'it''s FROM t",
+		`SELECT "abc FROM t`,
+		"SELECT [abc FROM t",
+		"SELECT `abc FROM t",
+		"SELECT x'abc' FROM t",
+		"SELECT x'0g' FROM t",
+		"SELECT $a FROM t",
+	} {
+		_, err := parseSqlStatement(query)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Expected %q to be syntax error, got: %v", query, err)
+		}
+	}
+}
+
+func TestSelectStatementWithOrderBy(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT name FROM apples ORDER BY color COLLATE NoCase DESC NULLS FIRST, -id, 2 ASC NULLS LAST")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []orderingTerm{
+		{expr: collateExpr{expr: columnRefExpr{name: "color"}, collation: "NoCase"}, desc: true, nulls: "FIRST"},
+		{expr: unaryExpr{operator: "-", operand: columnRefExpr{name: "id"}}},
+		{expr: literalExpr{value: int64(2)}, nulls: "LAST"},
+	}
+
+	orderBy := ast.(selectStatement).orderBy
+	if !reflect.DeepEqual(orderBy, expected) {
+		t.Errorf("Expected order by to be %+v, got: %+v", expected, orderBy)
+	}
+
+	_, err = parseSqlStatement("SELECT name FROM apples ORDER BY name NULLS")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected NULLS without FIRST or LAST to be syntax error, got: %v", err)
+	}
+}
+
+func TestSelectStatementWithLimit(t *testing.T) {
+	testCases := map[string][2]expression{
+		"SELECT name FROM apples LIMIT 10":              {literalExpr{value: int64(10)}, nil},
+		"SELECT name FROM apples LIMIT 10 OFFSET 1 + 1": {literalExpr{value: int64(10)}, binaryExpr{operator: "+", left: literalExpr{value: int64(1)}, right: literalExpr{value: int64(1)}}},
+		"SELECT name FROM apples LIMIT 5, 10":           {literalExpr{value: int64(10)}, literalExpr{value: int64(5)}},
+	}
+
+	for query, expected := range testCases {
+		ast, err := parseSqlStatement(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statement := ast.(selectStatement)
+		if !reflect.DeepEqual(statement.limit, expected[0]) || !reflect.DeepEqual(statement.offset, expected[1]) {
+			t.Errorf("Expected %q to have limit %+v and offset %+v, got: %+v and %+v", query, expected[0], expected[1], statement.limit, statement.offset)
+		}
+	}
+
+	_, err := parseSqlStatement("SELECT name FROM apples LIMIT")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected LIMIT without expression to be syntax error, got: %v", err)
+	}
+}
+
+func TestSelectStatementWithGroupBy(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT color, count(*) FROM apples WHERE id > 1 GROUP BY color, 2 HAVING count(*) > 1 ORDER BY color")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := ast.(selectStatement)
+	expectedGroupBy := []expression{columnRefExpr{name: "color"}, literalExpr{value: int64(2)}}
+	if !reflect.DeepEqual(statement.groupBy, expectedGroupBy) {
+		t.Errorf("Expected group by to be %+v, got: %+v", expectedGroupBy, statement.groupBy)
+	}
+
+	expectedHaving := binaryExpr{operator: ">", left: functionCallExpr{name: "count", args: []expression{}, star: true}, right: literalExpr{value: int64(1)}}
+	if !reflect.DeepEqual(statement.having, expectedHaving) {
+		t.Errorf("Expected having to be %+v, got: %+v", expectedHaving, statement.having)
+	}
+
+	if len(statement.orderBy) != 1 {
+		t.Errorf("Expected order by to follow group by, got: %+v", statement.orderBy)
+	}
+
+	ast, err = parseSqlStatement("SELECT count(*) FROM apples HAVING count(*) > 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statement := ast.(selectStatement); len(statement.groupBy) != 0 || statement.having == nil {
+		t.Errorf("Expected having without group by, got: %+v", statement)
+	}
+
+	_, err = parseSqlStatement("SELECT color FROM apples GROUP color")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected GROUP without BY to be syntax error, got: %v", err)
+	}
+}
+
+func TestAggregateFunctionCalls(t *testing.T) {
+	testCases := map[string]functionCallExpr{
+		"count(*)":                         {name: "count", args: []expression{}, star: true},
+		"count()":                          {name: "count", args: []expression{}},
+		"COUNT(DISTINCT color)":            {name: "count", args: []expression{columnRefExpr{name: "color"}}, distinct: true},
+		"sum(ALL id)":                      {name: "sum", args: []expression{columnRefExpr{name: "id"}}},
+		"group_concat(name, ', ')":         {name: "group_concat", args: []expression{columnRefExpr{name: "name"}, literalExpr{value: ", "}}},
+		"Group_Concat(DISTINCT name || 1)": {name: "group_concat", args: []expression{binaryExpr{operator: "||", left: columnRefExpr{name: "name"}, right: literalExpr{value: int64(1)}}}, distinct: true},
+	}
+
+	for call, expected := range testCases {
+		ast, err := parseSqlStatement("SELECT " + call + " FROM apples")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expr := ast.(selectStatement).columns[0].expr
+		if !reflect.DeepEqual(expr, expected) {
+			t.Errorf("Expected %q to be parsed as %+v, got: %+v", call, expected, expr)
+		}
+	}
+
+	_, err := parseSqlStatement("SELECT count(DISTINCT *) FROM apples")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected DISTINCT with star to be syntax error, got: %v", err)
+	}
+}
+
+func TestSelectStatementWithAliases(t *testing.T) {
+	ast, err := parseSqlStatement(`SELECT *, a.*, a.name AS "Name", color c, id 'x' FROM apples AS a ORDER BY c`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statement := ast.(selectStatement)
+	expected := []resultColumn{
+		{name: "*", star: true},
+		{name: "a.*", star: true, table: "a"},
+		{expr: columnRefExpr{table: "a", name: "name"}, name: "a.name", alias: "Name"},
+		{expr: columnRefExpr{name: "color"}, name: "color", alias: "c"},
+		{expr: columnRefExpr{name: "id"}, name: "id", alias: "x"},
+	}
+	if !reflect.DeepEqual(statement.columns, expected) {
+		t.Errorf("Expected columns to be %+v, got: %+v", expected, statement.columns)
+	}
+	if statement.from != (tableRef{name: "apples", alias: "a"}) {
+		t.Errorf("Expected table apples with alias a, got: %+v", statement.from)
+	}
+
+	// keywords following table name are not its alias
+	ast, err = parseSqlStatement("SELECT id FROM apples ORDER BY id LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from := ast.(selectStatement).from; from != (tableRef{name: "apples"}) {
+		t.Errorf("Expected no alias, got: %+v", from)
+	}
+
+	_, err = parseSqlStatement("SELECT a. FROM apples")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected qualifier without column to be syntax error, got: %v", err)
+	}
+}
+
+func TestSelectStatementWithJoins(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT * FROM customers c JOIN orders o ON o.customer_id = c.id LEFT OUTER JOIN cities USING (name), notes CROSS JOIN tags t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customers := tableRef{name: "customers", alias: "c"}
+	orders := joinClause{
+		left:  customers,
+		right: tableRef{name: "orders", alias: "o"},
+		kind:  "INNER",
+		on:    binaryExpr{operator: "=", left: columnRefExpr{table: "o", name: "customer_id"}, right: columnRefExpr{table: "c", name: "id"}},
+	}
+	cities := joinClause{left: orders, right: tableRef{name: "cities"}, kind: "LEFT", using: []string{"name"}}
+	notes := joinClause{left: cities, right: tableRef{name: "notes"}, kind: "INNER"}
+	expected := joinClause{left: notes, right: tableRef{name: "tags", alias: "t"}, kind: "CROSS"}
+
+	from := ast.(selectStatement).from
+	if !reflect.DeepEqual(from, expected) {
+		t.Errorf("Expected from to be %+v, got: %+v", expected, from)
+	}
+
+	_, err = parseSqlStatement("SELECT * FROM a RIGHT JOIN b ON a.id = b.id")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected RIGHT JOIN to be unsupported, got: %v", err)
+	}
+
+	_, err = parseSqlStatement("SELECT * FROM a JOIN b USING ()")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected empty USING to be syntax error, got: %v", err)
+	}
+}
+
+func TestSelectStatementWithSubqueries(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT (SELECT max(a) FROM u) FROM (SELECT a FROM t) d WHERE a NOT IN (1, 2) AND EXISTS (SELECT b FROM u) OR a IN (SELECT b FROM u)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := ast.(selectStatement)
+
+	subquery := func(column expression, name string, table string) selectStatement {
+		return selectStatement{columns: []resultColumn{{expr: column, name: name}}, from: tableRef{name: table}}
+	}
+	maxA := functionCallExpr{name: "max", args: []expression{columnRefExpr{name: "a"}}}
+	if expected := (subquerySyntax{statement: subquery(maxA, "max(a)", "u")}); !reflect.DeepEqual(statement.columns[0].expr, expected) {
+		t.Errorf("Expected scalar subquery %+v, got: %+v", expected, statement.columns[0].expr)
+	}
+
+	derived := subquery(columnRefExpr{name: "a"}, "a", "t")
+	if expected := (tableRef{alias: "d", subquery: &derived}); !reflect.DeepEqual(statement.from, expected) {
+		t.Errorf("Expected derived table %+v, got: %+v", expected, statement.from)
+	}
+
+	selectB := subquerySyntax{statement: subquery(columnRefExpr{name: "b"}, "b", "u")}
+	expectedWhere := binaryExpr{
+		operator: "OR",
+		left: binaryExpr{
+			operator: "AND",
+			left:     inExpr{expr: columnRefExpr{name: "a"}, not: true, list: []expression{literalExpr{value: int64(1)}, literalExpr{value: int64(2)}}},
+			right:    existsExpr{subquery: selectB},
+		},
+		right: inExpr{expr: columnRefExpr{name: "a"}, subquery: selectB},
+	}
+	if !reflect.DeepEqual(statement.where, expectedWhere) {
+		t.Errorf("Expected where to be %+v, got: %+v", expectedWhere, statement.where)
+	}
+
+	for _, query := range []string{"SELECT a FROM t WHERE a IN (SELECT b FROM u", "SELECT a FROM (t)", "SELECT a FROM t WHERE a IN 1"} {
+		_, err = parseSqlStatement(query)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Expected %q to be syntax error, got: %v", query, err)
+		}
+	}
+}
+
+func TestSelectStatementWithCommonTableExpressions(t *testing.T) {
+	ast, err := parseSqlStatement("WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n FROM t), u AS (SELECT a FROM v) SELECT n FROM t ORDER BY n LIMIT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := ast.(selectStatement)
+
+	one := resultColumn{expr: literalExpr{value: int64(1)}, name: "1"}
+	n := resultColumn{expr: columnRefExpr{name: "n"}, name: "n"}
+	expected := []commonTableExpr{
+		{
+			name:    "t",
+			columns: []string{"n"},
+			statement: selectStatement{
+				columns:  []resultColumn{one},
+				compound: []compoundSelect{{operator: "UNION ALL", statement: selectStatement{columns: []resultColumn{n}, from: tableRef{name: "t"}}}},
+			},
+		},
+		{name: "u", statement: selectStatement{columns: []resultColumn{{expr: columnRefExpr{name: "a"}, name: "a"}}, from: tableRef{name: "v"}}},
+	}
+	if !reflect.DeepEqual(statement.with, expected) {
+		t.Errorf("Expected common table expressions %+v, got: %+v", expected, statement.with)
+	}
+
+	// ORDER BY and LIMIT belong to the statement, not to the last select of compound
+	if len(statement.orderBy) != 1 || statement.limit == nil || len(statement.compound) != 0 {
+		t.Errorf("Expected ORDER BY and LIMIT of the statement, got: %+v", statement)
+	}
+
+	for _, query := range []string{"WITH t AS SELECT 1 SELECT * FROM t", "WITH t(a AS (SELECT 1) SELECT * FROM t", "WITH SELECT 1"} {
+		_, err = parseSqlStatement(query)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Expected %q to be syntax error, got: %v", query, err)
+		}
+	}
+}
+
+func TestSelectStatementWithCompoundSelect(t *testing.T) {
+	ast, err := parseSqlStatement("SELECT a FROM t UNION SELECT b FROM u INTERSECT SELECT c FROM v EXCEPT SELECT d FROM w UNION ALL SELECT 1 ORDER BY 1 LIMIT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := ast.(selectStatement)
+
+	operators := []string{}
+	for _, c := range statement.compound {
+		operators = append(operators, c.operator)
+		if c.statement.orderBy != nil || c.statement.limit != nil {
+			t.Errorf("Expected ORDER BY and LIMIT to belong to the compound select, got: %+v", c.statement)
+		}
+	}
+	if expected := []string{"UNION", "INTERSECT", "EXCEPT", "UNION ALL"}; !reflect.DeepEqual(operators, expected) {
+		t.Errorf("Expected operators %v, got: %v", expected, operators)
+	}
+	if len(statement.orderBy) != 1 || statement.limit != (literalExpr{value: int64(2)}) {
+		t.Errorf("Expected ORDER BY and LIMIT of the compound select, got: %+v", statement)
+	}
+
+	for _, query := range []string{"SELECT a FROM t UNION", "SELECT a FROM t ORDER BY a UNION SELECT b FROM u", "SELECT a FROM t EXCEPT ALL SELECT b FROM u"} {
+		_, err = parseSqlStatement(query)
+		if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Expected %q to be syntax error, got: %v", query, err)
+		}
+	}
+}
+
+func TestSelectStatementWithDistinct(t *testing.T) {
+	testCases := map[string]bool{
+		"SELECT DISTINCT color FROM apples":              true,
+		"SELECT ALL color FROM apples":                   false,
+		"SELECT color FROM apples":                       false,
+		"SELECT count(DISTINCT color) FROM apples":       false,
+		"SELECT a FROM t UNION SELECT DISTINCT b FROM u": false,
+	}
+
+	for query, expected := range testCases {
+		ast, err := parseSqlStatement(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if distinct := ast.(selectStatement).distinct; distinct != expected {
+			t.Errorf("Expected DISTINCT of %q to be %v, got: %v", query, expected, distinct)
+		}
+	}
+
+	_, err := parseSqlStatement("SELECT DISTINCT FROM apples")
+	if !errors.Is(err, ErrSyntax) {
+		t.Errorf("Expected select without columns to be syntax error, got: %v", err)
+	}
+}
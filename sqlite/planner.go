@@ -0,0 +1,1469 @@
+package sqlite
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+type planner struct {
+	reader fileReader
+	// executor runs subqueries while the query is executed
+	executor executor
+}
+
+type executionPlan struct {
+	// from reads the first table of FROM clause, joins add rows of the following tables to its rows
+	from  tableScan
+	joins []joinStep
+	// width is number of values of joined row, every table has its declared columns followed by the rowid
+	width int
+	// columns are names of result columns, projection holds expression computing each of them
+	columns    []string
+	projection []expression
+	// where holds conditions which can be checked only on joined rows, nil when there are none.
+	// Column references are bound to positions in joined row.
+	where expression
+	// aggregates are aggregate calls from select list, their results follow joined row values
+	aggregates []functionCallExpr
+	// groupBy holds expressions grouping table rows, aggregate query without them has single group
+	groupBy []expression
+	// having filters aggregated rows, nil when all groups are returned
+	having expression
+	// orderBy holds sort keys, result rows are in access path order when it is empty
+	orderBy []sortKey
+	// distinct is set when only distinct result rows are returned
+	distinct bool
+	// limit is negative when number of rows is not limited
+	limit  int64
+	offset int64
+	// compound combines rows of selects, when it is set the plan only describes result columns
+	compound *compoundPlan
+}
+
+// tableScan reads rows of one table using access path chosen from conditions on that table alone
+type tableScan struct {
+	table    createTableStatement
+	rootPage int
+	// offset is position of the first table value in joined row
+	offset int
+	// filter holds conditions on values of this table only, nil when all rows are read
+	filter     expression
+	indexSeek  *indexSeekPlan
+	rowidRange *keyRange
+	// subquery computes rows of derived table, table btree is not read then
+	subquery *executionPlan
+	// recursive computes rows of recursive common table expression
+	recursive *recursiveTable
+	// memory holds rows of table which is not stored in database file
+	memory *memoryTable
+	// distinctColumn is set when the query returns distinct values of this column and needs no other one,
+	// index on the column is then scanned so equal values come one after another
+	distinctColumn *int
+}
+
+// isDerived checks if rows are computed instead of being read from table btree,
+// such table has neither rowid nor indexes
+func (s tableScan) isDerived() bool {
+	return s.subquery != nil || s.recursive != nil || s.memory != nil
+}
+
+// isAggregate checks if result rows are computed from groups of table rows
+func (p executionPlan) isAggregate() bool {
+	return len(p.aggregates) > 0 || len(p.groupBy) > 0 || p.having != nil
+}
+
+// sortKey is bound ORDER BY term
+type sortKey struct {
+	expr       expression
+	desc       bool
+	nullsFirst bool
+	collation  string
+}
+
+// indexSeekPlan reads rowids from index btree instead of scanning whole table
+type indexSeekPlan struct {
+	indexName string
+	rootPage  int
+	keys      keyRange
+	// column is position of the indexed column in table row, rows are read in order of its values
+	column int
+	// covering is set when the query needs no other value than the column and rowid, table isn't read then
+	covering bool
+}
+
+// bound of key range, nil bound means range is not limited on that side
+type bound struct {
+	value     any
+	inclusive bool
+}
+
+type keyRange struct {
+	lower *bound
+	upper *bound
+	// compare orders keys the same way as they are stored in btree, compareValues of utf-8 text is used when nil
+	compare func(a, b any) int
+}
+
+func (k keyRange) compareKeys(a, b any) int {
+	if k.compare != nil {
+		return k.compare(a, b)
+	}
+	return compareValues(a, b, utf8Encoding)
+}
+
+func keyRangeFromCondition(operator string, value any) (keyRange, error) {
+	switch operator {
+	case "=":
+		return keyRange{lower: &bound{value: value, inclusive: true}, upper: &bound{value: value, inclusive: true}}, nil
+	case ">":
+		return keyRange{lower: &bound{value: value}}, nil
+	case ">=":
+		return keyRange{lower: &bound{value: value, inclusive: true}}, nil
+	// null is smallest value in index, it should never match range condition
+	case "<":
+		return keyRange{lower: &bound{value: nil}, upper: &bound{value: value}}, nil
+	case "<=":
+		return keyRange{lower: &bound{value: nil}, upper: &bound{value: value, inclusive: true}}, nil
+	default:
+		return keyRange{}, unsupportedError("seek by %v operator", operator)
+	}
+}
+
+// intersect narrows the range so it satisfies both ranges, used for conditions joined by AND
+func (k keyRange) intersect(other keyRange) keyRange {
+	return keyRange{
+		lower:   tighterBound(k.lower, other.lower, 1, k.compareKeys),
+		upper:   tighterBound(k.upper, other.upper, -1, k.compareKeys),
+		compare: k.compare,
+	}
+}
+
+// tighterBound returns more restrictive bound, direction is 1 for lower bounds and -1 for upper ones
+func tighterBound(a, b *bound, direction int, compare func(a, b any) int) *bound {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	cmp := compare(a.value, b.value) * direction
+	if cmp > 0 || (cmp == 0 && !a.inclusive) {
+		return a
+	}
+	return b
+}
+
+func (k keyRange) isEquality() bool {
+	return k.lower != nil && k.upper != nil && k.lower.inclusive && k.upper.inclusive && k.compareKeys(k.lower.value, k.upper.value) == 0
+}
+
+func (k keyRange) aboveLower(val any) bool {
+	if k.lower == nil {
+		return true
+	}
+	cmp := k.compareKeys(val, k.lower.value)
+	return cmp > 0 || (cmp == 0 && k.lower.inclusive)
+}
+
+func (k keyRange) belowUpper(val any) bool {
+	if k.upper == nil {
+		return true
+	}
+	cmp := k.compareKeys(val, k.upper.value)
+	return cmp < 0 || (cmp == 0 && k.upper.inclusive)
+}
+
+func (k keyRange) contains(val any) bool {
+	return k.aboveLower(val) && k.belowUpper(val)
+}
+
+func createPlanner(reader fileReader) planner {
+	return planner{
+		reader:   reader,
+		executor: newExecutor(reader),
+	}
+}
+
+// sqlite doesn't allow more tables in one join either
+const maxJoinTables = 64
+
+func (p planner) preparePlan(statement selectStatement) (executionPlan, error) {
+	plan, _, err := p.prepareSelect(statement, nil, nil)
+	return plan, err
+}
+
+// prepareSelect plans query or subquery, outer is binder of the query subquery is part of and it is nil
+// for top level query. Values of outer query used by subquery are returned as its correlations.
+// Scope holds common table expressions the statement can use as tables.
+func (p planner) prepareSelect(statement selectStatement, outer *columnBinder, scope *cteScope) (executionPlan, []*correlation, error) {
+	if len(statement.with) > 0 {
+		var err error
+		scope, err = newCteScope(statement.with, scope)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+	}
+	if len(statement.compound) > 0 {
+		return p.prepareCompound(statement, outer, scope)
+	}
+
+	refs, joins := joinedTables(statement.from)
+	if len(refs) > maxJoinTables {
+		return executionPlan{}, nil, fmt.Errorf("at most %v tables in a join", maxJoinTables)
+	}
+
+	binder := columnBinder{planner: p, outer: outer, scope: scope, allowAggregates: true}
+	for _, ref := range refs {
+		table, err := p.bindTable(ref, scope)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+		table.offset = binder.width()
+		binder.tables = append(binder.tables, table)
+	}
+
+	return p.prepareQuery(statement, &binder, joins)
+}
+
+// prepareQuery plans statement which tables of FROM clause are bound already
+func (p planner) prepareQuery(statement selectStatement, binder *columnBinder, joins []joinClause) (executionPlan, []*correlation, error) {
+
+	// join conditions are bound first as USING hides columns of the right table from the rest of the query.
+	// Condition of inner join filters joined rows the same way as WHERE, only LEFT JOIN keeps its own.
+	joinConditions := make([]expression, len(joins))
+	var innerConditions expression
+	binder.allowAggregates = false
+	for i, join := range joins {
+		var condition expression
+		for _, name := range join.using {
+			equal, err := binder.usingCondition(i+1, name)
+			if err != nil {
+				return executionPlan{}, nil, err
+			}
+			condition = andExpr(condition, equal)
+		}
+
+		if join.on != nil {
+			on, err := binder.bind(join.on)
+			if err != nil {
+				return executionPlan{}, nil, err
+			}
+			condition = andExpr(condition, on)
+		}
+
+		if join.kind != "LEFT" {
+			innerConditions = andExpr(innerConditions, condition)
+			continue
+		}
+		if binder.referencedTables(condition) >= tableSet(1)<<(i+2) {
+			return executionPlan{}, nil, fmt.Errorf("ON clause references tables to its right")
+		}
+		joinConditions[i] = condition
+	}
+	binder.allowAggregates = true
+
+	plan := executionPlan{width: binder.width(), distinct: statement.distinct}
+	aliases := map[string]expression{}
+	for _, column := range statement.columns {
+		if column.star {
+			columns, projection, err := binder.expandStar(column.table)
+			if err != nil {
+				return executionPlan{}, nil, err
+			}
+			plan.columns = append(plan.columns, columns...)
+			plan.projection = append(plan.projection, projection...)
+			continue
+		}
+
+		expr, err := binder.bind(column.expr)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+		plan.columns = append(plan.columns, binder.resultColumnName(column, expr))
+		plan.projection = append(plan.projection, expr)
+
+		if _, ok := aliases[strings.ToLower(column.alias)]; column.alias != "" && !ok {
+			aliases[strings.ToLower(column.alias)] = expr
+		}
+	}
+	binder.aliases = aliases
+
+	where := innerConditions
+	var err error
+	if statement.where != nil {
+		binder.allowAggregates = false
+		bound, err := binder.bind(statement.where)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+		where = andExpr(where, bound)
+		binder.allowAggregates = true
+	}
+
+	plan.groupBy, err = bindGroupBy(binder, statement.groupBy, plan.projection)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+	if statement.having != nil {
+		plan.having, err = binder.bind(statement.having)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+	}
+
+	plan.orderBy, err = bindOrderBy(binder, statement.orderBy, plan.projection)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+	plan.aggregates = binder.aggregates
+
+	err = checkGrouping(plan)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+
+	plan.limit, err = p.limitValue(statement.limit, -1)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+	plan.offset, err = p.limitValue(statement.offset, 0)
+	if err != nil {
+		return executionPlan{}, nil, err
+	}
+	// negative offset is the same as no offset
+	plan.offset = max(plan.offset, 0)
+
+	scans, steps := distributeConditions(binder, joins, joinConditions, where, &plan)
+	if len(steps) == 0 {
+		scans[0].distinctColumn = distinctScanColumn(plan, scans[0])
+	}
+	for i := range scans {
+		err = p.chooseAccessPath(&scans[i])
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+	}
+
+	plan.from = scans[0]
+	for i := range steps {
+		steps[i].scan = scans[i+1]
+		err = p.chooseJoinStrategy(binder, &steps[i], i+1)
+		if err != nil {
+			return executionPlan{}, nil, err
+		}
+	}
+	plan.joins = steps
+
+	return plan, binder.correlations, nil
+}
+
+// bindTable reads table of FROM clause, subquery of derived table is planned on its own as it can't
+// reference other tables of the query. Common table expression hides table with the same name.
+func (p planner) bindTable(ref tableRef, scope *cteScope) (boundTable, error) {
+	if ref.subquery != nil {
+		plan, _, err := p.prepareSelect(*ref.subquery, nil, scope)
+		if err != nil {
+			return boundTable{}, err
+		}
+		return boundTable{name: ref.alias, table: derivedTable(ref.alias, plan), subquery: &plan, using: map[string]bool{}}, nil
+	}
+
+	if cte, inSubquery := scope.lookup(ref.name); cte != nil {
+		return p.bindCommonTable(cte, ref.alias, inSubquery)
+	}
+
+	table, rootPage, err := p.readTable(ref.name)
+	if err != nil {
+		return boundTable{}, err
+	}
+
+	// table can be referenced only by its alias when it has one
+	name := table.tableName
+	if ref.alias != "" {
+		name = ref.alias
+	}
+	return boundTable{name: name, table: table, rootPage: rootPage, using: map[string]bool{}}, nil
+}
+
+// readTable returns declaration and root page of the table
+func (p planner) readTable(name string) (createTableStatement, int, error) {
+	schema, err := p.reader.getSchemaByTablename(name)
+	if err != nil {
+		return createTableStatement{}, 0, err
+	}
+
+	sql, err := parseSqlStatement(schema.sqlText)
+	if err != nil {
+		return createTableStatement{}, 0, err
+	}
+
+	table, ok := sql.(createTableStatement)
+	if !ok {
+		// for simplicity allow only create table, will be extended later
+		return createTableStatement{}, 0, unsupportedError("reading schema, expected create table statement")
+	}
+	if table.withoutRowid {
+		return createTableStatement{}, 0, unsupportedError("WITHOUT ROWID table %v", table.tableName)
+	}
+
+	return table, int(schema.rootPage), nil
+}
+
+// joinedTables flattens FROM clause into tables in join order, joins[i] joins tables[i+1] to the tables before it
+func joinedTables(from fromItem) ([]tableRef, []joinClause) {
+	if from == nil {
+		return nil, nil
+	}
+
+	join, ok := from.(joinClause)
+	if !ok {
+		return []tableRef{from.(tableRef)}, nil
+	}
+
+	tables, joins := joinedTables(join.left)
+	return append(tables, join.right), append(joins, join)
+}
+
+// distributeConditions moves every condition to the earliest place it can be checked. Condition on single table
+// filters its scan, the other ones are checked by the join which adds the last table they use. Values of the right
+// table of LEFT JOIN are null when nothing matches, so WHERE conditions on them must wait for the joined row.
+func distributeConditions(binder *columnBinder, joins []joinClause, joinConditions []expression, where expression, plan *executionPlan) ([]tableScan, []joinStep) {
+	scans := make([]tableScan, len(binder.tables))
+	for i, table := range binder.tables {
+		scans[i] = tableScan{
+			table:     table.table,
+			rootPage:  table.rootPage,
+			offset:    table.offset,
+			subquery:  table.subquery,
+			recursive: table.recursive,
+			memory:    table.memory,
+		}
+	}
+	// select without FROM reads single row without values
+	if len(scans) == 0 {
+		scans = []tableScan{{memory: &memoryTable{rows: [][]any{{}}}}}
+	}
+	steps := make([]joinStep, len(joins))
+	for i, join := range joins {
+		steps[i].outer = join.kind == "LEFT"
+	}
+
+	// ON condition decides which right rows match, those using only the right table filter its scan
+	for i, condition := range joinConditions {
+		for _, conjunct := range conjuncts(condition) {
+			if binder.referencedTables(conjunct) == tableSet(1)<<(i+1) {
+				scans[i+1].filter = andExpr(scans[i+1].filter, conjunct)
+			} else {
+				steps[i].condition = andExpr(steps[i].condition, conjunct)
+			}
+		}
+	}
+
+	for _, conjunct := range conjuncts(where) {
+		tables := binder.referencedTables(conjunct)
+		last := bits.Len64(uint64(tables)) - 1
+		switch {
+		case last <= 0:
+			scans[0].filter = andExpr(scans[0].filter, conjunct)
+		case steps[last-1].outer:
+			plan.where = andExpr(plan.where, conjunct)
+		case tables == tableSet(1)<<last:
+			scans[last].filter = andExpr(scans[last].filter, conjunct)
+		default:
+			steps[last-1].condition = andExpr(steps[last-1].condition, conjunct)
+		}
+	}
+
+	return scans, steps
+}
+
+// andExpr joins conditions by AND, nil condition is always true
+func andExpr(a, b expression) expression {
+	if a == nil {
+		return b
+	}
+	return binaryExpr{operator: "AND", left: a, right: b}
+}
+
+// chooseAccessPath picks how rows matching scan filter are read. Rowid seek is the cheapest one,
+// index is preferred only when it is compared by equality and rowid is not.
+func (p planner) chooseAccessPath(scan *tableScan) error {
+	scan.rowidRange, scan.indexSeek = nil, nil
+	if scan.isDerived() {
+		return nil
+	}
+
+	terms := indexTerms(scan.filter)
+	for i := range terms {
+		terms[i].column -= scan.offset
+	}
+
+	rowidRange, err := chooseRowidRange(scan.table, terms)
+	if err != nil {
+		return err
+	}
+	indexSeek, err := p.chooseIndex(scan.table, terms)
+	if err != nil {
+		return err
+	}
+	if rowidRange != nil && (rowidRange.isEquality() || indexSeek == nil || !indexSeek.keys.isEquality()) {
+		scan.rowidRange = rowidRange
+		return nil
+	}
+	scan.indexSeek = indexSeek
+
+	if scan.distinctColumn != nil {
+		return p.chooseCoveringIndex(scan)
+	}
+	return nil
+}
+
+// chooseCoveringIndex reads distinct column from its index when the filter needs no other column,
+// the whole index is scanned when no condition picked index seek
+func (p planner) chooseCoveringIndex(scan *tableScan) error {
+	column := *scan.distinctColumn
+	if scan.indexSeek != nil && scan.indexSeek.column != column || !isCoveredByIndex(scan.filter, *scan, column) {
+		return nil
+	}
+
+	if scan.indexSeek == nil {
+		indexes, err := p.seekableIndexes(scan.table)
+		if err != nil {
+			return err
+		}
+		i := slices.IndexFunc(indexes, func(index seekableIndex) bool { return index.column == column })
+		if i == -1 {
+			return nil
+		}
+		scan.indexSeek = &indexSeekPlan{indexName: indexes[i].name, rootPage: indexes[i].rootPage, column: column}
+	}
+
+	scan.indexSeek.covering = true
+	return nil
+}
+
+// chooseAccessPaths picks access paths of all scans again, correlated subquery is planned before values
+// of outer query are known and conditions comparing them with indexed columns can be used only once they are set
+func (p planner) chooseAccessPaths(plan executionPlan) (executionPlan, error) {
+	if plan.compound != nil {
+		compound := *plan.compound
+		compound.selects = slices.Clone(compound.selects)
+		for i := range compound.selects {
+			var err error
+			compound.selects[i], err = p.chooseAccessPaths(compound.selects[i])
+			if err != nil {
+				return executionPlan{}, err
+			}
+		}
+		plan.compound = &compound
+		return plan, nil
+	}
+
+	err := p.chooseScanAccessPaths(&plan.from)
+	if err != nil {
+		return executionPlan{}, err
+	}
+
+	plan.joins = slices.Clone(plan.joins)
+	for i := range plan.joins {
+		err = p.chooseScanAccessPaths(&plan.joins[i].scan)
+		if err != nil {
+			return executionPlan{}, err
+		}
+	}
+	return plan, nil
+}
+
+// chooseScanAccessPaths picks access path of the scan, derived table holding rows of compound select
+// with ORDER BY can depend on outer query so access paths of its subquery are picked as well
+func (p planner) chooseScanAccessPaths(scan *tableScan) error {
+	if scan.subquery == nil {
+		return p.chooseAccessPath(scan)
+	}
+
+	subquery, err := p.chooseAccessPaths(*scan.subquery)
+	if err != nil {
+		return err
+	}
+	scan.subquery = &subquery
+	return nil
+}
+
+// resultColumnName names result column the same way as sqlite, alias is used when given, column reference
+// is named by declared column and other expressions by their text
+func (b *columnBinder) resultColumnName(column resultColumn, expr expression) string {
+	if column.alias != "" {
+		return column.alias
+	}
+
+	bound, ok := expr.(boundColumnExpr)
+	if _, isColumn := column.expr.(columnRefExpr); !isColumn || !ok || bound.index >= b.width() {
+		return column.name
+	}
+
+	table, err := b.tableAt(bound.index)
+	if err != nil {
+		return column.name
+	}
+	if index := bound.index - table.offset; index < len(table.table.columns) {
+		return table.table.columns[index].name
+	}
+	// rowid is named by the column which is its alias
+	for _, tableColumn := range table.table.columns {
+		if tableColumn.isRowidAlias() {
+			return tableColumn.name
+		}
+	}
+	return "rowid"
+}
+
+// orderByAlias returns result column when ORDER BY term is its alias, alias takes precedence over table column
+func orderByAlias(binder *columnBinder, expr expression) expression {
+	collate, hasCollation := expr.(collateExpr)
+	if hasCollation {
+		expr = collate.expr
+	}
+
+	column, ok := expr.(columnRefExpr)
+	if !ok || column.table != "" {
+		return nil
+	}
+	alias, ok := binder.aliases[strings.ToLower(column.name)]
+	if !ok {
+		return nil
+	}
+
+	if hasCollation {
+		return collateExpr{expr: alias, collation: collate.collation}
+	}
+	return alias
+}
+
+// resultColumnReference replaces integer constant of ORDER BY or GROUP BY term with result column
+// at that position starting from 1, binding already bound expression of the column doesn't change it
+func resultColumnReference(expr expression, term int, clause string, projection []expression) (expression, error) {
+	// position can have collation, e.g. ORDER BY 2 COLLATE NOCASE
+	positionExpr := expr
+	collate, hasCollation := expr.(collateExpr)
+	if hasCollation {
+		positionExpr = collate.expr
+	}
+
+	literal, ok := positionExpr.(literalExpr)
+	if !ok {
+		return expr, nil
+	}
+	position, ok := literal.value.(int64)
+	if !ok {
+		return expr, nil
+	}
+
+	if position < 1 || position > int64(len(projection)) {
+		return nil, fmt.Errorf("%v %v term out of range - should be between 1 and %v", ordinal(term+1), clause, len(projection))
+	}
+	expr = projection[position-1]
+	if hasCollation {
+		expr = collateExpr{expr: expr, collation: collate.collation}
+	}
+	return expr, nil
+}
+
+// bindGroupBy binds GROUP BY terms, they are evaluated on table rows so they can't use aggregates
+func bindGroupBy(binder *columnBinder, terms []expression, projection []expression) ([]expression, error) {
+	groupBy := []expression{}
+	for i, term := range terms {
+		expr, err := resultColumnReference(term, i, "GROUP BY", projection)
+		if err != nil {
+			return nil, err
+		}
+
+		aggregates := len(binder.aggregates)
+		expr, err = binder.bind(expr)
+		if err != nil {
+			return nil, err
+		}
+		if len(binder.aggregates) > aggregates || referencesAggregate(expr, binder.width()) {
+			return nil, fmt.Errorf("aggregate functions are not allowed in the GROUP BY clause")
+		}
+		groupBy = append(groupBy, expr)
+	}
+
+	return groupBy, nil
+}
+
+// referencesAggregate checks if bound expression uses result of aggregate, results follow values of joined row
+func referencesAggregate(expr expression, width int) bool {
+	found := false
+	walkExpr(expr, func(e expression) bool {
+		if column, ok := e.(boundColumnExpr); ok && column.index >= width {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// checkGrouping verifies that expressions evaluated on aggregated rows use table columns only
+// inside aggregates or as part of GROUP BY expression, value of such column is the same in whole group
+func checkGrouping(plan executionPlan) error {
+	if !plan.isAggregate() {
+		return nil
+	}
+	if plan.having != nil && len(plan.groupBy) == 0 && len(plan.aggregates) == 0 {
+		return fmt.Errorf("HAVING clause on a non-aggregate query")
+	}
+
+	exprs := append([]expression{}, plan.projection...)
+	if plan.having != nil {
+		exprs = append(exprs, plan.having)
+	}
+	for _, key := range plan.orderBy {
+		exprs = append(exprs, key.expr)
+	}
+
+	// collation changes only how values are grouped, the value without it can be used as well
+	groupBy := append([]expression{}, plan.groupBy...)
+	for _, expr := range plan.groupBy {
+		if collate, ok := expr.(collateExpr); ok {
+			groupBy = append(groupBy, collate.expr)
+		}
+	}
+
+	for _, expr := range exprs {
+		var err error
+		walkExpr(expr, func(e expression) bool {
+			for _, groupExpr := range groupBy {
+				if sameExpr(e, groupExpr) {
+					return false
+				}
+			}
+			if column, ok := e.(boundColumnExpr); ok && column.index < plan.width {
+				err = fmt.Errorf("column %v must appear in the GROUP BY clause or be used in an aggregate function", column.name)
+			}
+			return err == nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindOrderBy binds ORDER BY terms, integer constant refers to result column by its position
+func bindOrderBy(binder *columnBinder, terms []orderingTerm, projection []expression) ([]sortKey, error) {
+	keys := []sortKey{}
+	for i, term := range terms {
+		var err error
+		expr := orderByAlias(binder, term.expr)
+		if expr == nil {
+			expr, err = resultColumnReference(term.expr, i, "ORDER BY", projection)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		expr, err = binder.bind(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		// nulls are the smallest values, they are first in ascending order unless specified otherwise
+		collation, _ := exprCollation(expr)
+		keys = append(keys, sortKey{
+			expr:       expr,
+			desc:       term.desc,
+			nullsFirst: term.nulls == "FIRST" || (term.nulls == "" && !term.desc),
+			collation:  collation,
+		})
+	}
+
+	return keys, nil
+}
+
+// limitValue evaluates LIMIT or OFFSET expression, it can't reference columns and must be an integer
+func (p planner) limitValue(expr expression, defaultValue int64) (int64, error) {
+	if expr == nil {
+		return defaultValue, nil
+	}
+
+	binder := columnBinder{planner: p}
+	bound, err := binder.bind(expr)
+	if err != nil {
+		return 0, err
+	}
+	val, err := evalExpr(bound, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	// text and real are accepted when they hold integer value
+	switch v := applyAffinity(integerAffinity, val).(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), nil
+		}
+	}
+	return 0, fmt.Errorf("datatype mismatch")
+}
+
+// ordinal formats number as 1st, 2nd, 3rd, 4th and so on
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%v%v", n, suffix)
+}
+
+// argumentCount is the allowed number of function arguments
+type argumentCount struct {
+	min int
+	max int
+}
+
+// aggregate functions by name, results of other functions depend only on their arguments
+var aggregateFunctions = map[string]argumentCount{
+	"count":        {min: 0, max: 1},
+	"sum":          {min: 1, max: 1},
+	"total":        {min: 1, max: 1},
+	"avg":          {min: 1, max: 1},
+	"min":          {min: 1, max: 1},
+	"max":          {min: 1, max: 1},
+	"group_concat": {min: 1, max: 2},
+}
+
+// columnBinder resolves column references to positions in joined row, the row holds values of every table
+// of FROM clause, declared columns followed by the rowid, and then results of aggregates
+type columnBinder struct {
+	planner         planner
+	tables          []boundTable
+	allowAggregates bool
+	aggregates      []functionCallExpr
+	// aliases hold bound result columns by lower cased alias, they are used when there is no such table column
+	aliases map[string]expression
+	// outer binds columns of outer query which are not found in subquery, nil for top level query
+	outer *columnBinder
+	// correlations are values of outer query used by subquery
+	correlations []*correlation
+	// scope holds common table expressions usable by subqueries
+	scope *cteScope
+}
+
+// boundTable is table of FROM clause, its values start at offset in joined row
+type boundTable struct {
+	// name is the name columns can be qualified with, alias when table has one
+	name     string
+	table    createTableStatement
+	rootPage int
+	offset   int
+	// using holds lower cased names of columns joined by USING, unqualified name refers to the left table column
+	using map[string]bool
+	// subquery is set for derived table
+	subquery *executionPlan
+	// recursive is set for recursive common table expression and memory for table which rows are kept in memory
+	recursive *recursiveTable
+	memory    *memoryTable
+}
+
+func (t boundTable) width() int {
+	return len(t.table.columns) + 1
+}
+
+// width returns number of values of joined row
+func (b *columnBinder) width() int {
+	width := 0
+	for _, table := range b.tables {
+		width += table.width()
+	}
+	return width
+}
+
+// tableAt returns table which values are at the position of joined row
+func (b *columnBinder) tableAt(index int) (boundTable, error) {
+	for _, table := range b.tables {
+		if index < table.offset+table.width() {
+			return table, nil
+		}
+	}
+	return boundTable{}, fmt.Errorf("position %v is not a table column", index)
+}
+
+// rowid can be referenced by any of these names unless table declares column with the same name
+var rowidNames = []string{"rowid", "oid", "_rowid_"}
+
+// tableColumnIndex returns position of column in table row, rowid follows declared columns, -1 means no such column
+func tableColumnIndex(table createTableStatement, name string) int {
+	for i, column := range table.columns {
+		if strings.EqualFold(column.name, name) {
+			return i
+		}
+	}
+
+	for _, rowidName := range rowidNames {
+		if strings.EqualFold(rowidName, name) {
+			return len(table.columns)
+		}
+	}
+
+	return -1
+}
+
+// tableColumnCollation returns collation of declared column, BINARY is used when column doesn't declare any
+func tableColumnCollation(table createTableStatement, index int) string {
+	if index == len(table.columns) || table.columns[index].collation == "" {
+		return "BINARY"
+	}
+	return table.columns[index].collation
+}
+
+// columnAffinity returns affinity of declared column, rowid is always integer
+func (b *columnBinder) columnAffinity(index int) (affinity, error) {
+	table, err := b.tableAt(index)
+	if err != nil {
+		return "", err
+	}
+	index -= table.offset
+	if index == len(table.table.columns) {
+		return integerAffinity, nil
+	}
+	return columnAffinity(table.table.columns[index].columnType), nil
+}
+
+func (b *columnBinder) columnCollation(index int) (string, error) {
+	table, err := b.tableAt(index)
+	if err != nil {
+		return "", err
+	}
+	return tableColumnCollation(table.table, index-table.offset), nil
+}
+
+// tableSet holds bit for every table of FROM clause by its position
+type tableSet uint64
+
+// referencedTables returns tables which values are used by bound expression
+func (b *columnBinder) referencedTables(expr expression) tableSet {
+	var tables tableSet
+	walkExpr(expr, func(e expression) bool {
+		if column, ok := e.(boundColumnExpr); ok && column.index < b.width() {
+			for i, table := range b.tables {
+				if column.index >= table.offset && column.index < table.offset+table.width() {
+					tables |= tableSet(1) << i
+				}
+			}
+		}
+		return true
+	})
+	return tables
+}
+
+// expandStar returns names and references of columns selected by * or table.*, column joined
+// by USING is selected by * only once
+func (b *columnBinder) expandStar(qualifier string) ([]string, []expression, error) {
+	names := []string{}
+	projection := []expression{}
+	found := false
+	for _, table := range b.tables {
+		if qualifier != "" && !strings.EqualFold(qualifier, table.name) {
+			continue
+		}
+		found = true
+
+		for i, column := range table.table.columns {
+			if qualifier == "" && table.using[strings.ToLower(column.name)] {
+				continue
+			}
+			bound, err := b.boundColumn(table.offset+i, column.name)
+			if err != nil {
+				return nil, nil, err
+			}
+			names = append(names, column.name)
+			projection = append(projection, bound)
+		}
+	}
+
+	if len(b.tables) == 0 {
+		return nil, nil, fmt.Errorf("no tables specified")
+	}
+	if !found {
+		return nil, nil, noSuchTableError(qualifier)
+	}
+	return names, projection, nil
+}
+
+// usingCondition binds column of USING as equality of the column of the right table and of the first table
+// on its left which has it, the right table column is then referenced only when qualified
+func (b *columnBinder) usingCondition(right int, name string) (expression, error) {
+	rightTable := b.tables[right]
+	rightIndex := slices.IndexFunc(rightTable.table.columns, func(column createTableColumn) bool {
+		return strings.EqualFold(column.name, name)
+	})
+
+	for _, leftTable := range b.tables[:right] {
+		leftIndex := slices.IndexFunc(leftTable.table.columns, func(column createTableColumn) bool {
+			return strings.EqualFold(column.name, name)
+		})
+		if leftIndex == -1 || rightIndex == -1 || leftTable.using[strings.ToLower(name)] {
+			continue
+		}
+
+		left, err := b.boundColumn(leftTable.offset+leftIndex, leftTable.table.columns[leftIndex].name)
+		if err != nil {
+			return nil, err
+		}
+		right, err := b.boundColumn(rightTable.offset+rightIndex, rightTable.table.columns[rightIndex].name)
+		if err != nil {
+			return nil, err
+		}
+
+		rightTable.using[strings.ToLower(name)] = true
+		return binaryExpr{operator: "=", left: left, right: right}, nil
+	}
+
+	return nil, fmt.Errorf("cannot join using column %v - column not present in both tables", name)
+}
+
+func (b *columnBinder) bind(expr expression) (expression, error) {
+	var err error
+
+	switch e := expr.(type) {
+	case columnRefExpr:
+		return b.bindColumn(e)
+	case unaryExpr:
+		e.operand, err = b.bind(e.operand)
+		return e, err
+	case binaryExpr:
+		e.encoding = b.planner.reader.header.textEncoding()
+		e.left, err = b.bind(e.left)
+		if err != nil {
+			return nil, err
+		}
+		e.right, err = b.bind(e.right)
+		return e, err
+	case betweenExpr:
+		e.encoding = b.planner.reader.header.textEncoding()
+		for _, operand := range []*expression{&e.expr, &e.low, &e.high} {
+			*operand, err = b.bind(*operand)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return e, nil
+	case collateExpr:
+		if _, ok := collations[strings.ToUpper(e.collation)]; !ok {
+			return nil, fmt.Errorf("no such collation sequence: %v", e.collation)
+		}
+		e.collation = strings.ToUpper(e.collation)
+		e.expr, err = b.bind(e.expr)
+		return e, err
+	case functionCallExpr:
+		return b.bindAggregate(e)
+	case subquerySyntax:
+		return b.bindScalarSubquery(e.statement)
+	case existsExpr:
+		e.subquery, err = b.bindSubquery(e.subquery.(subquerySyntax).statement)
+		return e, err
+	case inExpr:
+		e.expr, err = b.bind(e.expr)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]expression, len(e.list))
+		for i, value := range e.list {
+			list[i], err = b.bind(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		e.list = list
+		if e.subquery != nil {
+			e.subquery, err = b.bind(e.subquery)
+		}
+		return e, err
+	default:
+		return expr, nil
+	}
+}
+
+// walkExpr calls visit for bound expression and its subexpressions, children are skipped when visit returns false
+func walkExpr(expr expression, visit func(expression) bool) {
+	if !visit(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case unaryExpr:
+		walkExpr(e.operand, visit)
+	case binaryExpr:
+		walkExpr(e.left, visit)
+		walkExpr(e.right, visit)
+	case betweenExpr:
+		walkExpr(e.expr, visit)
+		walkExpr(e.low, visit)
+		walkExpr(e.high, visit)
+	case collateExpr:
+		walkExpr(e.expr, visit)
+	case inExpr:
+		walkExpr(e.expr, visit)
+		for _, value := range e.list {
+			walkExpr(value, visit)
+		}
+		if e.subquery != nil {
+			walkExpr(e.subquery, visit)
+		}
+	case existsExpr:
+		walkExpr(e.subquery, visit)
+	case *subqueryExpr:
+		// subquery uses values of this query only through its correlations
+		for _, c := range e.correlations {
+			walkExpr(c.expr, visit)
+		}
+	}
+}
+
+// sameExpr checks if bound expressions compute the same value, columns are compared by their position
+// as the same column can be written in different case
+func sameExpr(a, b expression) bool {
+	switch exprA := a.(type) {
+	case boundColumnExpr:
+		exprB, ok := b.(boundColumnExpr)
+		return ok && exprA.index == exprB.index
+	case unaryExpr:
+		exprB, ok := b.(unaryExpr)
+		return ok && exprA.operator == exprB.operator && sameExpr(exprA.operand, exprB.operand)
+	case binaryExpr:
+		exprB, ok := b.(binaryExpr)
+		return ok && exprA.operator == exprB.operator && sameExpr(exprA.left, exprB.left) && sameExpr(exprA.right, exprB.right)
+	case betweenExpr:
+		exprB, ok := b.(betweenExpr)
+		return ok && exprA.not == exprB.not && sameExpr(exprA.expr, exprB.expr) && sameExpr(exprA.low, exprB.low) && sameExpr(exprA.high, exprB.high)
+	case collateExpr:
+		exprB, ok := b.(collateExpr)
+		return ok && exprA.collation == exprB.collation && sameExpr(exprA.expr, exprB.expr)
+	case correlatedColumnExpr:
+		exprB, ok := b.(correlatedColumnExpr)
+		return ok && exprA.correlation == exprB.correlation
+	case *subqueryExpr:
+		// every subquery is planned on its own, only the same one computes the same value
+		return a == b
+	case inExpr, existsExpr:
+		return false
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+func (b *columnBinder) bindColumn(column columnRefExpr) (expression, error) {
+	name := column.name
+	if column.table != "" {
+		name = column.table + "." + column.name
+	}
+
+	index, err := b.columnIndex(column)
+	if err != nil {
+		return nil, err
+	}
+	if index != -1 {
+		return b.boundColumn(index, column.name)
+	}
+
+	alias, ok := b.aliases[strings.ToLower(column.name)]
+	if (!ok || column.table != "") && b.outer != nil {
+		outerExpr, err := b.outer.bindColumn(column)
+		if err != nil {
+			return nil, err
+		}
+		return b.correlate(outerExpr, column.name), nil
+	}
+	if !ok || column.table != "" {
+		return nil, noSuchColumnError(name)
+	}
+	if !b.allowAggregates && referencesAggregate(alias, b.width()) {
+		return nil, fmt.Errorf("misuse of aliased aggregate %v", column.name)
+	}
+	return alias, nil
+}
+
+// columnIndex returns position of column in joined row, -1 means there is no such column.
+// Unqualified name must be unique among all tables.
+func (b *columnBinder) columnIndex(column columnRefExpr) (int, error) {
+	index := -1
+	for _, table := range b.tables {
+		if column.table != "" && !strings.EqualFold(column.table, table.name) {
+			continue
+		}
+
+		tableIndex := tableColumnIndex(table.table, column.name)
+		if tableIndex == -1 || (column.table == "" && table.using[strings.ToLower(column.name)]) {
+			continue
+		}
+		if index != -1 && column.table != "" {
+			return -1, fmt.Errorf("ambiguous column name: %v.%v", column.table, column.name)
+		}
+		if index != -1 {
+			return -1, fmt.Errorf("ambiguous column name: %v", column.name)
+		}
+		index = table.offset + tableIndex
+	}
+
+	return index, nil
+}
+
+func (b *columnBinder) boundColumn(index int, name string) (boundColumnExpr, error) {
+	affinity, err := b.columnAffinity(index)
+	if err != nil {
+		return boundColumnExpr{}, err
+	}
+	collation, err := b.columnCollation(index)
+	if err != nil {
+		return boundColumnExpr{}, err
+	}
+	return boundColumnExpr{index: index, name: name, affinity: affinity, collation: collation}, nil
+}
+
+// bindAggregate replaces aggregate call with reference to its result, nested aggregates are not allowed
+func (b *columnBinder) bindAggregate(call functionCallExpr) (expression, error) {
+	arguments, ok := aggregateFunctions[call.name]
+	if !ok {
+		return nil, unsupportedError("no such function: %v", call.name)
+	}
+	// min and max with more arguments are scalar functions returning the smallest or the biggest argument
+	if (call.name == "min" || call.name == "max") && len(call.args) > 1 {
+		return nil, unsupportedError("scalar function %v()", call.name)
+	}
+	if len(call.args) < arguments.min || len(call.args) > arguments.max || (call.star && call.name != "count") {
+		return nil, fmt.Errorf("wrong number of arguments to function %v()", call.name)
+	}
+	if call.distinct && len(call.args) != 1 {
+		return nil, fmt.Errorf("DISTINCT aggregates must have exactly one argument")
+	}
+	if !b.allowAggregates {
+		return nil, fmt.Errorf("misuse of aggregate function %v()", call.name)
+	}
+
+	b.allowAggregates = false
+	args := make([]expression, len(call.args))
+	for i, arg := range call.args {
+		var err error
+		args[i], err = b.bind(arg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	b.allowAggregates = true
+
+	call.args = args
+	b.aggregates = append(b.aggregates, call)
+
+	return boundColumnExpr{index: b.width() + len(b.aggregates) - 1, name: call.name}, nil
+}
+
+// indexTerm is condition in form column op constant taken from where clause, it can be answered by btree seek
+type indexTerm struct {
+	column   int
+	operator string
+	value    any
+	// collation of the comparison, btree seek compares keys as BINARY
+	collation string
+}
+
+// operators with sides swapped, constant op column is turned into column op constant
+var flippedOperators = map[string]string{"=": "=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}
+
+// indexTerms returns conditions which must be true for every row matching where clause,
+// conditions joined by OR can't be used as any of them alone is enough
+func indexTerms(where expression) []indexTerm {
+	terms := []indexTerm{}
+	for _, conjunct := range conjuncts(where) {
+		switch e := conjunct.(type) {
+		case binaryExpr:
+			if term, ok := comparisonTerm(e.operator, e.left, e.right, comparisonCollation(e.left, e.right)); ok {
+				terms = append(terms, term)
+			}
+		case betweenExpr:
+			if e.not {
+				continue
+			}
+			low, lowOk := comparisonTerm(">=", e.expr, e.low, comparisonCollation(e.expr, e.low))
+			high, highOk := comparisonTerm("<=", e.expr, e.high, comparisonCollation(e.expr, e.high))
+			if lowOk && highOk {
+				terms = append(terms, low, high)
+			}
+		}
+	}
+
+	return terms
+}
+
+// conjuncts splits expression into parts joined by AND
+func conjuncts(expr expression) []expression {
+	if expr == nil {
+		return nil
+	}
+
+	if and, ok := expr.(binaryExpr); ok && and.operator == "AND" {
+		return append(conjuncts(and.left), conjuncts(and.right)...)
+	}
+	return []expression{expr}
+}
+
+func comparisonTerm(operator string, left, right expression, collation string) (indexTerm, bool) {
+	flipped, ok := flippedOperators[operator]
+	if !ok {
+		return indexTerm{}, false
+	}
+
+	term, ok := columnConstantTerm(operator, left, right)
+	if !ok {
+		term, ok = columnConstantTerm(flipped, right, left)
+	}
+	term.collation = collation
+	return term, ok
+}
+
+func columnConstantTerm(operator string, columnExpr, constantExpr expression) (indexTerm, bool) {
+	column, ok := columnExpr.(boundColumnExpr)
+	if !ok {
+		return indexTerm{}, false
+	}
+
+	// expression referencing columns can't be evaluated without a row
+	value, err := evalExpr(constantExpr, nil)
+	if err != nil || value == nil {
+		return indexTerm{}, false
+	}
+
+	// btree keys are stored with column affinity applied, constant is converted the same way as for comparison
+	affinity := comparisonAffinity(column.affinity, exprAffinity(constantExpr))
+	return indexTerm{column: column.index, operator: operator, value: applyAffinity(affinity, value)}, true
+}
+
+// chooseRowidRange combines all conditions on the rowid into one range of rowids
+func chooseRowidRange(table createTableStatement, terms []indexTerm) (*keyRange, error) {
+	var rowidRange *keyRange
+	for _, term := range terms {
+		isRowid := term.column == len(table.columns) || table.columns[term.column].isRowidAlias()
+		if !isRowid {
+			continue
+		}
+
+		conditionRange, err := keyRangeFromCondition(term.operator, term.value)
+		if err != nil {
+			return nil, err
+		}
+		if rowidRange != nil {
+			conditionRange = rowidRange.intersect(conditionRange)
+		}
+		rowidRange = &conditionRange
+	}
+
+	return rowidRange, nil
+}
+
+// seekableIndex is index which entries are ordered by BINARY value of one table column
+type seekableIndex struct {
+	name     string
+	rootPage int
+	// column is position of the first indexed column in table row
+	column int
+}
+
+// seekableIndexes returns indexes of the table which can be searched by value of their first column
+func (p planner) seekableIndexes(table createTableStatement) ([]seekableIndex, error) {
+	indexSchemas, err := p.reader.getIndexSchemas(table.tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []seekableIndex{}
+	for _, indexSchema := range indexSchemas {
+		// indexes using syntax not supported by the parser are not used
+		sql, err := parseSqlStatement(indexSchema.sqlText)
+		if err != nil {
+			continue
+		}
+
+		createIndex, ok := sql.(createIndexStatement)
+		if !ok || !isSeekable(createIndex) {
+			continue
+		}
+
+		column := tableColumnIndex(table, createIndex.columns[0].name)
+		if column == -1 {
+			continue
+		}
+
+		// index is ordered by column collation unless index declares its own one
+		indexCollation := createIndex.columns[0].collation
+		if indexCollation == "" {
+			indexCollation = tableColumnCollation(table, column)
+		}
+		if !isBinaryCollation(indexCollation) {
+			continue
+		}
+
+		indexes = append(indexes, seekableIndex{name: createIndex.indexName, rootPage: int(indexSchema.rootPage), column: column})
+	}
+
+	return indexes, nil
+}
+
+// chooseIndex picks index which first column is used in where condition, equality is preferred over range
+func (p planner) chooseIndex(table createTableStatement, terms []indexTerm) (*indexSeekPlan, error) {
+	var indexSeek *indexSeekPlan
+	isEquality := false
+
+	indexes, err := p.seekableIndexes(table)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, index := range indexes {
+		for _, term := range terms {
+			if term.column != index.column || isEquality || !isBinaryCollation(term.collation) {
+				continue
+			}
+			if indexSeek != nil && term.operator != "=" {
+				continue
+			}
+
+			keys, err := keyRangeFromCondition(term.operator, term.value)
+			if err != nil {
+				return nil, err
+			}
+			indexSeek = &indexSeekPlan{
+				indexName: index.name,
+				rootPage:  index.rootPage,
+				keys:      keys,
+				column:    index.column,
+			}
+			isEquality = term.operator == "="
+		}
+	}
+
+	return indexSeek, nil
+}
+
+// isSeekable checks if index keys are ordered by plain column value and the index covers all rows
+func isSeekable(index createIndexStatement) bool {
+	first := index.columns[0]
+	return index.where == nil && first.name != "" && !first.desc
+}
+
+func isBinaryCollation(collation string) bool {
+	return collation == "" || collation == "BINARY"
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"errors"
+	"testing"
+)
+
+func conditionRange(t *testing.T, operator string, value any) keyRange {
+	t.Helper()
+
+	keys, err := keyRangeFromCondition(operator, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestKeyRangeFromCondition(t *testing.T) {
+	equal := conditionRange(t, "=", "Red")
+
+	if !equal.contains("Red") {
+		t.Errorf("expected equality range to contain compared value")
+	}
+
+	if equal.contains("Reddish") || equal.contains("Blue") {
+		t.Errorf("expected equality range to contain only compared value")
+	}
+
+	greater := conditionRange(t, ">", "Red")
+
+	if greater.contains("Red") || !greater.contains("Yellow") {
+		t.Errorf("expected greater range to contain only values above compared value")
+	}
+
+	less := conditionRange(t, "<", "Red")
+
+	if less.contains(nil) {
+		t.Errorf("expected null to never match range condition")
+	}
+
+	if !less.contains("Blue") || less.contains("Red") {
+		t.Errorf("expected less range to contain only values below compared value")
+	}
+
+	if _, err := keyRangeFromCondition("!=", "Red"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected inequality to be unsupported, got: %v", err)
+	}
+}
+
+func TestKeyRangeIntersect(t *testing.T) {
+	between := conditionRange(t, ">=", int64(10)).intersect(conditionRange(t, "<=", int64(20)))
+
+	if !between.contains(int64(10)) || !between.contains(int64(20)) || between.contains(int64(21)) || between.contains(int64(9)) {
+		t.Errorf("expected range to contain values from 10 to 20")
+	}
+
+	narrowed := between.intersect(conditionRange(t, ">", int64(15)))
+
+	if narrowed.contains(int64(15)) || !narrowed.contains(int64(16)) {
+		t.Errorf("expected range to be narrowed by exclusive lower bound")
+	}
+
+	equal := narrowed.intersect(conditionRange(t, "=", int64(18)))
+
+	if !equal.isEquality() {
+		t.Errorf("expected range to be narrowed to single value")
+	}
+}
+
+func TestTableAtOutsideJoinedRow(t *testing.T) {
+	binder := &columnBinder{tables: []boundTable{{table: createTableStatement{columns: []createTableColumn{{name: "a"}}}}}}
+
+	if _, err := binder.tableAt(1); err != nil {
+		t.Errorf("expected rowid to belong to the table, got: %v", err)
+	}
+	if _, err := binder.tableAt(2); err == nil {
+		t.Errorf("expected position after the last table to fail")
+	}
+}
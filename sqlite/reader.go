@@ -0,0 +1,246 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+type fileReader struct {
+	databaseFilePath string
+	databaseFile     *os.File
+	pageSize         int
+	header           dbHeader
+	wal              *wal
+	cache            *pageCache
+}
+
+// newReader opens database with page cache of the size suggested by database header
+func newReader(databaseFilePath string) (fileReader, error) {
+	databaseFile, err := os.Open(databaseFilePath)
+	if err != nil {
+		return fileReader{}, err
+	}
+
+	header := make([]byte, 100)
+
+	_, err = databaseFile.ReadAt(header, 0)
+	if err != nil || string(header[:16]) != "SQLite format 3\x00" {
+		databaseFile.Close()
+		return fileReader{}, fmt.Errorf("file is not a database: %v", databaseFilePath)
+	}
+
+	reader := fileReader{
+		databaseFilePath: databaseFilePath,
+		databaseFile:     databaseFile,
+		header:           parseDatabaseHeader(header),
+	}
+	reader.pageSize = reader.header.pageSize()
+
+	reader.cache = newPageCache(cacheSizeInPages(int32(binary.BigEndian.Uint32(reader.header.defaultPageCacheSize)), reader.pageSize))
+
+	// first page can be changed by transactions still in wal, header has to be read again
+	reader.wal, err = readWal(databaseFilePath, reader.pageSize)
+	if err != nil {
+		databaseFile.Close()
+		return fileReader{}, err
+	}
+
+	header, err = reader.readHeader()
+	if err != nil {
+		reader.Close()
+		return fileReader{}, err
+	}
+	reader.header = parseDatabaseHeader(header)
+
+	return reader, nil
+}
+
+func (r fileReader) Close() error {
+	if r.wal != nil {
+		r.wal.walFile.Close()
+	}
+	return r.databaseFile.Close()
+}
+
+// cacheStats returns number of page reads served from cache and number of reads which hit the disk
+func (r fileReader) cacheStats() (int, int) {
+	return r.cache.stats()
+}
+
+// readPage reads and parses a btree page
+func (r fileReader) readPage(pageNumber int) (btreePage, error) {
+	page, err := r.read(pageNumber)
+	if err != nil {
+		return btreePage{}, err
+	}
+	return r.parsePage(page, pageNumber)
+}
+
+// readChildPage reads page which number is stored in child pointer of interior page
+func (r fileReader) readChildPage(pageNumberData []byte) (btreePage, error) {
+	return r.readPage(int(binary.BigEndian.Uint32(pageNumberData)))
+}
+
+func (r fileReader) seqRead(rootPage int) ([]btreePage, error) {
+	pageParsed, err := r.readPage(rootPage)
+	if err != nil {
+		return nil, err
+	}
+	return r.readRecusrive(pageParsed)
+}
+
+func (r fileReader) readRecusrive(pageParsed btreePage) ([]btreePage, error) {
+	if !pageParsed.btreeHeader.isInterior() {
+		return []btreePage{pageParsed}, nil
+	}
+
+	pages := []btreePage{}
+	for i := 0; i <= len(pageParsed.cells); i++ {
+		childPages, err := r.readChild(pageParsed.childPointer(i))
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, childPages...)
+	}
+
+	return pages, nil
+}
+
+func (r fileReader) readChild(pageNumberData []byte) ([]btreePage, error) {
+	pageParsed, err := r.readChildPage(pageNumberData)
+	if err != nil {
+		return nil, err
+	}
+	return r.readRecusrive(pageParsed)
+}
+
+// compareStoredKeys orders values the same way as index btree, text is compared by its bytes in database encoding
+func (r fileReader) compareStoredKeys(a, b any) int {
+	return compareValues(a, b, r.header.textEncoding())
+}
+
+// seekRowid binary searches table btree down to the single leaf which can contain the rowid
+func (r fileReader) seekRowid(rootPage int, rowid int64) (btreeCell, bool, error) {
+	pageNumber := rootPage
+	for {
+		pageParsed, err := r.readPage(pageNumber)
+		if err != nil {
+			return btreeCell{}, false, err
+		}
+		cells := pageParsed.cells
+		// interior cell key is the largest rowid in its left child
+		i := sort.Search(len(cells), func(i int) bool { return cells[i].rowId >= rowid })
+
+		if !pageParsed.btreeHeader.isInterior() {
+			if i < len(cells) && cells[i].rowId == rowid {
+				return cells[i], true, nil
+			}
+			return btreeCell{}, false, nil
+		}
+
+		pageNumber = int(binary.BigEndian.Uint32(pageParsed.childPointer(i)))
+	}
+}
+
+// usableSize is the page size without the reserved space at the end of every page
+func (r fileReader) usableSize() int {
+	return r.pageSize - int(r.header.reservedBytes)
+}
+
+// read returns page content, committed version from wal takes precedence over the one in database file.
+// Returned slice is shared with the cache, it must not be modified.
+func (r fileReader) read(pageNumber int) ([]byte, error) {
+	if pageNumber < 1 {
+		return nil, corruptError("invalid page number %v", pageNumber)
+	}
+
+	if page, ok := r.cache.get(pageNumber); ok {
+		return page, nil
+	}
+
+	var file io.ReaderAt = r.databaseFile
+	offset := int64(pageNumber-1) * int64(r.pageSize)
+	if walOffset, ok := r.wal.pageOffset(pageNumber); ok {
+		file = r.wal.walFile
+		offset = walOffset
+	}
+
+	page := make([]byte, r.pageSize)
+
+	_, err := file.ReadAt(page, offset)
+	if errors.Is(err, io.EOF) {
+		return nil, corruptError("page %v is past the end of the file", pageNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.put(pageNumber, page)
+
+	return page, nil
+
+}
+
+func (r fileReader) readHeader() ([]byte, error) {
+	page, err := r.read(1)
+	if err != nil {
+		return nil, err
+	}
+	return page[:100], nil
+}
+
+func (r fileReader) getSchemas() ([]dbSchema, error) {
+	pages, err := r.seqRead(1)
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := []dbSchema{}
+	for _, page := range pages {
+		pageSchemas, err := r.parseDataBaseSchemas(page)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, pageSchemas...)
+	}
+
+	return schemas, nil
+}
+
+func (r fileReader) getSchemaByTablename(tableName string) (dbSchema, error) {
+	schemas, err := r.getSchemas()
+	if err != nil {
+		return dbSchema{}, err
+	}
+
+	for _, item := range schemas {
+		if item.schemaType == "table" && strings.EqualFold(item.tableName, tableName) {
+			return item, nil
+		}
+	}
+
+	return dbSchema{}, noSuchTableError(tableName)
+
+}
+
+func (r fileReader) getIndexSchemas(tableName string) ([]dbSchema, error) {
+	schemas, err := r.getSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []dbSchema{}
+	for _, item := range schemas {
+		// automatic indexes (e.g. for unique constraint) have no sql text
+		if item.schemaType == "index" && strings.EqualFold(item.tableName, tableName) && item.sqlText != "" {
+			indexes = append(indexes, item)
+		}
+	}
+
+	return indexes, nil
+}
@@ -0,0 +1,331 @@
+package sqlite
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"os"
+	"slices"
+)
+
+// default amount of memory used by rows kept in memory before they are spilled to temporary file
+const defaultSortMemoryLimit = 64 << 20
+
+// sorter orders rows by sort keys, each row starts with values of its keys. Rows are kept in memory
+// until memory limit is reached, then they are sorted and written as one run to temporary file.
+// Runs are merged when sorted rows are read.
+type sorter struct {
+	keys        []sortKey
+	encoding    uint32
+	memoryLimit int
+	rows        [][]any
+	memoryUsed  int
+	runs        []*os.File
+}
+
+func newSorter(keys []sortKey, encoding uint32, memoryLimit int) *sorter {
+	return &sorter{keys: keys, encoding: encoding, memoryLimit: memoryLimit}
+}
+
+// compare orders rows by their keys, nulls are placed according to the key regardless of direction
+func (s *sorter) compare(a, b []any) int {
+	for i, key := range s.keys {
+		valA, valB := a[i], b[i]
+		if valA == nil || valB == nil {
+			if valA == nil && valB == nil {
+				continue
+			}
+			if (valA == nil) == key.nullsFirst {
+				return -1
+			}
+			return 1
+		}
+
+		cmp := compareCollated(valA, valB, key.collation, s.encoding)
+		if key.desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func (s *sorter) add(row []any) error {
+	s.rows = append(s.rows, row)
+	s.memoryUsed += rowSize(row)
+
+	if s.memoryUsed >= s.memoryLimit {
+		return s.spill()
+	}
+	return nil
+}
+
+// rowSize estimates memory used by the row
+func rowSize(row []any) int {
+	size := 24
+	for _, val := range row {
+		size += 16
+		switch v := val.(type) {
+		case string:
+			size += len(v)
+		case []byte:
+			size += len(v)
+		}
+	}
+	return size
+}
+
+// spill writes rows kept in memory as sorted run
+func (s *sorter) spill() error {
+	slices.SortStableFunc(s.rows, s.compare)
+
+	file, err := os.CreateTemp("", "sqlite-sort-*")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, file)
+
+	writer := bufio.NewWriter(file)
+	for _, row := range s.rows {
+		err = writeRow(writer, row)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	s.rows = nil
+	s.memoryUsed = 0
+	return nil
+}
+
+// writeRow stores row in temporary file as its length followed by record, runSource reads it back
+func writeRow(writer io.Writer, row []any) error {
+	record := encodeRecord(row)
+	err := binary.Write(writer, binary.BigEndian, uint32(len(record)))
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(record)
+	return err
+}
+
+// sorted returns iterator over all added rows in sort order
+func (s *sorter) sorted() (*mergeIterator, error) {
+	slices.SortStableFunc(s.rows, s.compare)
+
+	// runs go before rows in memory so rows with equal keys keep order in which they were added
+	sources := []rowSource{}
+	for _, run := range s.runs {
+		_, err := run.Seek(0, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &runSource{reader: bufio.NewReader(run)})
+	}
+	sources = append(sources, &memorySource{rows: s.rows})
+
+	iterator := &mergeIterator{sorter: s}
+	for i, source := range sources {
+		row, ok, err := source.next()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			iterator.items = append(iterator.items, mergeItem{row: row, source: source, order: i})
+		}
+	}
+	heap.Init(iterator)
+
+	return iterator, nil
+}
+
+// close removes temporary files
+func (s *sorter) close() error {
+	var closeErr error
+	for _, run := range s.runs {
+		err := run.Close()
+		if err == nil {
+			err = os.Remove(run.Name())
+		}
+		if err != nil && closeErr == nil {
+			closeErr = err
+		}
+	}
+	s.runs = nil
+
+	return closeErr
+}
+
+// rowSource returns rows one at a time, sorted run is read through it
+type rowSource interface {
+	next() ([]any, bool, error)
+}
+
+type memorySource struct {
+	rows [][]any
+}
+
+func (m *memorySource) next() ([]any, bool, error) {
+	if len(m.rows) == 0 {
+		return nil, false, nil
+	}
+	row := m.rows[0]
+	m.rows = m.rows[1:]
+	return row, true, nil
+}
+
+type runSource struct {
+	reader *bufio.Reader
+}
+
+func (r *runSource) next() ([]any, bool, error) {
+	var size uint32
+	err := binary.Read(r.reader, binary.BigEndian, &size)
+	if err == io.EOF {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	record := make([]byte, size)
+	_, err = io.ReadFull(r.reader, record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	row, err := parseRecord(record)
+	if err != nil {
+		return nil, false, err
+	}
+	return row, true, nil
+}
+
+type mergeItem struct {
+	row    []any
+	source rowSource
+	// order of the source, it breaks ties so merge is stable
+	order int
+}
+
+// mergeIterator merges sorted runs using heap of their current rows
+type mergeIterator struct {
+	sorter *sorter
+	items  []mergeItem
+}
+
+func (m *mergeIterator) Len() int { return len(m.items) }
+
+func (m *mergeIterator) Less(i, j int) bool {
+	cmp := m.sorter.compare(m.items[i].row, m.items[j].row)
+	if cmp != 0 {
+		return cmp < 0
+	}
+	return m.items[i].order < m.items[j].order
+}
+
+func (m *mergeIterator) Swap(i, j int) { m.items[i], m.items[j] = m.items[j], m.items[i] }
+
+func (m *mergeIterator) Push(x any) { m.items = append(m.items, x.(mergeItem)) }
+
+func (m *mergeIterator) Pop() any {
+	item := m.items[len(m.items)-1]
+	m.items = m.items[:len(m.items)-1]
+	return item
+}
+
+// next returns the smallest remaining row, false is returned when all rows were read
+func (m *mergeIterator) next() ([]any, bool, error) {
+	if len(m.items) == 0 {
+		return nil, false, nil
+	}
+
+	row := m.items[0].row
+	nextRow, ok, err := m.items[0].source.next()
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		m.items[0].row = nextRow
+		heap.Fix(m, 0)
+	} else {
+		heap.Pop(m)
+	}
+
+	return row, true, nil
+}
+
+// topN keeps only n smallest rows in max heap, it is used when ORDER BY is followed by LIMIT
+// so only rows which can be returned are kept in memory
+type topN struct {
+	sorter *sorter
+	n      int
+	items  []mergeItem
+	added  int
+}
+
+func newTopN(sorter *sorter, n int) *topN {
+	return &topN{sorter: sorter, n: n}
+}
+
+// less orders rows by keys, rows added earlier go first when keys are equal
+func (t *topN) less(a, b mergeItem) bool {
+	cmp := t.sorter.compare(a.row, b.row)
+	if cmp != 0 {
+		return cmp < 0
+	}
+	return a.order < b.order
+}
+
+func (t *topN) Len() int { return len(t.items) }
+
+// Less makes the heap keep the biggest row on the top, it is replaced when smaller row is added
+func (t *topN) Less(i, j int) bool { return t.less(t.items[j], t.items[i]) }
+
+func (t *topN) Swap(i, j int) { t.items[i], t.items[j] = t.items[j], t.items[i] }
+
+func (t *topN) Push(x any) { t.items = append(t.items, x.(mergeItem)) }
+
+func (t *topN) Pop() any {
+	item := t.items[len(t.items)-1]
+	t.items = t.items[:len(t.items)-1]
+	return item
+}
+
+func (t *topN) add(row []any) error {
+	item := mergeItem{row: row, order: t.added}
+	t.added++
+
+	if len(t.items) < t.n {
+		heap.Push(t, item)
+	} else if t.n > 0 && t.less(item, t.items[0]) {
+		t.items[0] = item
+		heap.Fix(t, 0)
+	}
+	return nil
+}
+
+// sorted returns kept rows in sort order
+func (t *topN) sorted() rowSource {
+	slices.SortFunc(t.items, func(a, b mergeItem) int {
+		if t.less(a, b) {
+			return -1
+		}
+		return 1
+	})
+
+	rows := make([][]any, len(t.items))
+	for i, item := range t.items {
+		rows[i] = item.row
+	}
+	return &memorySource{rows: rows}
+}
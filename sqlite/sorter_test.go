@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sortRows(t *testing.T, s *sorter, rows [][]any) [][]any {
+	t.Helper()
+
+	for _, row := range rows {
+		err := s.add(row)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sorted, err := s.sorted()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := [][]any{}
+	for {
+		row, ok, err := sorted.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return result
+		}
+		result = append(result, row)
+	}
+}
+
+func TestSorterSpillsRunsToTemporaryFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+
+	rows := [][]any{}
+	for i := int64(0); i < 1000; i++ {
+		// second value keeps original position, it checks that sort is stable
+		rows = append(rows, []any{(i * 7919) % 100, i})
+	}
+
+	s := newSorter([]sortKey{{nullsFirst: true}}, utf8Encoding, 1024)
+	sorted := sortRows(t, s, rows)
+
+	if len(s.runs) < 2 {
+		t.Errorf("expected rows to be spilled into multiple runs, got: %v", len(s.runs))
+	}
+
+	if len(sorted) != len(rows) {
+		t.Fatalf("expected %v rows, got: %v", len(rows), len(sorted))
+	}
+	for i := 1; i < len(sorted); i++ {
+		previous, current := sorted[i-1], sorted[i]
+		if compareValues(previous[0], current[0], utf8Encoding) > 0 {
+			t.Fatalf("expected rows to be sorted, got %v before %v", previous, current)
+		}
+		if compareValues(previous[0], current[0], utf8Encoding) == 0 && compareValues(previous[1], current[1], utf8Encoding) > 0 {
+			t.Fatalf("expected equal keys to keep insertion order, got %v before %v", previous, current)
+		}
+	}
+
+	err := s.close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(tempDir, "*"))
+	if len(files) != 0 {
+		t.Errorf("expected temporary files to be removed, got: %v", files)
+	}
+}
+
+func TestSorterKeys(t *testing.T) {
+	rows := [][]any{
+		{"b", int64(1)},
+		{nil, int64(2)},
+		{"B", int64(3)},
+		{"a", nil},
+		{"a", int64(5)},
+	}
+
+	testCases := []struct {
+		name     string
+		keys     []sortKey
+		expected []int
+	}{
+		{
+			name:     "ascending with nulls first",
+			keys:     []sortKey{{nullsFirst: true}, {nullsFirst: true}},
+			expected: []int{1, 2, 3, 4, 0},
+		},
+		{
+			name:     "descending with nulls last",
+			keys:     []sortKey{{desc: true}, {desc: true}},
+			expected: []int{0, 4, 3, 2, 1},
+		},
+		{
+			name:     "nocase with nulls last",
+			keys:     []sortKey{{collation: "NOCASE"}, {desc: true, nullsFirst: true}},
+			expected: []int{3, 4, 2, 0, 1},
+		},
+	}
+
+	for _, testCase := range testCases {
+		sorted := sortRows(t, newSorter(testCase.keys, utf8Encoding, defaultSortMemoryLimit), rows)
+
+		expected := [][]any{}
+		for _, i := range testCase.expected {
+			expected = append(expected, rows[i])
+		}
+		if !reflect.DeepEqual(sorted, expected) {
+			t.Errorf("%v: expected %v, got: %v", testCase.name, expected, sorted)
+		}
+	}
+}
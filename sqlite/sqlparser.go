@@ -0,0 +1,1814 @@
+package sqlite
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type tokenizer struct {
+	input string
+	index int
+}
+
+func (t *tokenizer) eof() bool {
+	return t.index >= len(t.input)
+}
+
+func (t *tokenizer) peek() byte {
+	if t.eof() {
+		return '$'
+	}
+	return t.input[t.index]
+}
+
+func (t *tokenizer) next() byte {
+	t.index++
+	return t.peek()
+}
+
+// skipWhiteSpaces skips spaces and comments, block comment is allowed to be unterminated at the end of input
+func (t *tokenizer) skipWhiteSpaces() {
+	for !t.eof() {
+		switch {
+		case strings.IndexByte(" \t\n\r\f", t.peek()) != -1:
+			t.next()
+		case strings.HasPrefix(t.input[t.index:], "--"):
+			end := strings.IndexByte(t.input[t.index:], '\n')
+			if end == -1 {
+				t.index = len(t.input)
+			} else {
+				t.index += end + 1
+			}
+		case strings.HasPrefix(t.input[t.index:], "/*"):
+			end := strings.Index(t.input[t.index+2:], "*/")
+			if end == -1 {
+				t.index = len(t.input)
+			} else {
+				t.index += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+type tokenType string
+
+type sqlToken struct {
+	tokenType tokenType
+	value     string
+	// position of the token in the input and its raw text, used for error reporting
+	position int
+	raw      string
+	// quoted identifier is never treated as keyword
+	quoted bool
+}
+
+const (
+	selectToken             tokenType = "SelectToken"
+	tableToken              tokenType = "tableToken"
+	indexToken              tokenType = "indexToken"
+	uniqueToken             tokenType = "uniqueToken"
+	onToken                 tokenType = "onToken"
+	createToken             tokenType = "CreateToken"
+	whereToken              tokenType = "WhereToken"
+	fromToken               tokenType = "FromToken"
+	identifierToken         tokenType = "IdentifierToken"
+	logicalOperatorOrToken  tokenType = "LogicalOperatorOrToken"
+	logicalOperatorAndToken tokenType = "LogicalOperatorAndToken"
+	lParenToken             tokenType = "lParenToken"
+	rParenToken             tokenType = "rParenToken"
+	starToken               tokenType = "starToken"
+	commaToken              tokenType = "commaToken"
+	dotToken                tokenType = "dotToken"
+	opToken                 tokenType = "opToken"
+	literalToken            tokenType = "literalToken"
+	numberToken             tokenType = "numberToken"
+	blobToken               tokenType = "blobToken"
+	betweenToken            tokenType = "betweenToken"
+	notToken                tokenType = "notToken"
+	semicolonToken          tokenType = "semicolonToken"
+	eofToken                tokenType = "eofToken"
+)
+
+var clauseKeywords = map[string]sqlToken{
+	"SELECT":  sqlToken{tokenType: selectToken},
+	"CREATE":  sqlToken{tokenType: createToken},
+	"TABLE":   sqlToken{tokenType: tableToken},
+	"INDEX":   sqlToken{tokenType: indexToken},
+	"UNIQUE":  sqlToken{tokenType: uniqueToken},
+	"ON":      sqlToken{tokenType: onToken},
+	"FROM":    sqlToken{tokenType: fromToken},
+	"WHERE":   sqlToken{tokenType: whereToken},
+	"AND":     sqlToken{tokenType: logicalOperatorAndToken},
+	"OR":      sqlToken{tokenType: logicalOperatorOrToken},
+	"BETWEEN": sqlToken{tokenType: betweenToken},
+	"NOT":     sqlToken{tokenType: notToken},
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isAlphaNumerical(char byte) bool {
+	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+func (t *tokenizer) syntaxError(start int, message string) error {
+	return &SyntaxError{Position: start, Near: t.input[start:t.index], Message: message}
+}
+
+// parseChars reads keyword or unquoted identifier, keywords are recognized only when not quoted
+func (t *tokenizer) parseChars() (sqlToken, error) {
+	start := t.index
+	if t.peek() == '$' {
+		t.next()
+		return sqlToken{}, t.syntaxError(start, "unrecognized token")
+	}
+	t.skipIdentifierChars()
+
+	if start == t.index {
+		t.next()
+		return sqlToken{}, t.syntaxError(start, "unrecognized token")
+	}
+
+	stringOutput := t.input[start:t.index]
+	if val, ok := clauseKeywords[strings.ToUpper(stringOutput)]; ok {
+		return val, nil
+	}
+
+	return sqlToken{tokenType: identifierToken, value: stringOutput}, nil
+}
+
+func isHexDigit(char byte) bool {
+	return isDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+}
+
+func (t *tokenizer) skipDigits() {
+	for isDigit(t.peek()) {
+		t.next()
+	}
+}
+
+// numberParse reads integer (12), real (1.5, .5, 1e10) or hex (0x1F) literal, token value holds its text
+func (t *tokenizer) numberParse() (sqlToken, error) {
+	start := t.index
+	if t.peek() == '0' && t.index+1 < len(t.input) && (t.input[t.index+1] == 'x' || t.input[t.index+1] == 'X') {
+		t.next()
+		t.next()
+		digitsStart := t.index
+		for isHexDigit(t.peek()) {
+			t.next()
+		}
+		if t.index > digitsStart && !t.isIdentifierChar() {
+			if _, err := strconv.ParseUint(t.input[digitsStart:t.index], 16, 64); err != nil {
+				return sqlToken{}, t.syntaxError(start, "hex literal too big")
+			}
+		}
+	} else {
+		t.skipDigits()
+		if t.peek() == '.' {
+			t.next()
+			t.skipDigits()
+		}
+		if t.peek() == 'e' || t.peek() == 'E' {
+			exponent := t.index
+			char := t.next()
+			if char == '+' || char == '-' {
+				char = t.next()
+			}
+			if !isDigit(char) {
+				t.index = exponent
+				t.skipIdentifierChars()
+				return sqlToken{}, t.syntaxError(start, "unrecognized token")
+			}
+			t.skipDigits()
+		}
+	}
+
+	// number can't be directly followed by identifier, 12abc and 0x are not valid tokens
+	if t.isIdentifierChar() || t.input[start:t.index] == "0x" || t.input[start:t.index] == "0X" {
+		t.skipIdentifierChars()
+		return sqlToken{}, t.syntaxError(start, "unrecognized token")
+	}
+
+	return sqlToken{tokenType: numberToken, value: t.input[start:t.index]}, nil
+}
+
+// identifiers can contain any non ASCII character, $ can't be the first one
+func (t *tokenizer) isIdentifierChar() bool {
+	char := t.peek()
+	return !t.eof() && (isAlphaNumerical(char) || char == '_' || char == '$' || char >= 0x80)
+}
+
+func (t *tokenizer) skipIdentifierChars() {
+	for t.isIdentifierChar() {
+		t.next()
+	}
+}
+
+// quoted reads text up to the closing quote, the quote written twice stands for the quote itself
+func (t *tokenizer) quoted(closing byte, escapable bool) (string, bool) {
+	var output strings.Builder
+	t.next()
+	for !t.eof() {
+		char := t.peek()
+		t.next()
+		if char != closing {
+			output.WriteByte(char)
+			continue
+		}
+		if !escapable || t.eof() || t.peek() != closing {
+			return output.String(), true
+		}
+		output.WriteByte(closing)
+		t.next()
+	}
+
+	return "", false
+}
+
+// string literal, token value holds the text without quotes and with doubled quotes unescaped
+func (t *tokenizer) singleQuoteParse() (sqlToken, error) {
+	start := t.index
+	text, ok := t.quoted('\'', true)
+	if !ok {
+		return sqlToken{}, t.syntaxError(start, "missing ending '")
+	}
+
+	return sqlToken{tokenType: literalToken, value: text}, nil
+}
+
+// blob literal X'0A1B', token value holds decoded bytes
+func (t *tokenizer) blobParse() (sqlToken, error) {
+	start := t.index
+	t.next()
+	hexOutput, ok := t.quoted('\'', false)
+	if !ok {
+		return sqlToken{}, t.syntaxError(start, "missing ending '")
+	}
+
+	decoded, err := hex.DecodeString(hexOutput)
+	if err != nil {
+		return sqlToken{}, t.syntaxError(start, "malformed blob literal")
+	}
+
+	return sqlToken{tokenType: blobToken, value: string(decoded)}, nil
+}
+
+// quoted identifier "name", `name` or [name], quotes in the first two forms are escaped by doubling them
+func (t *tokenizer) quotedIdentifierParse(closing byte) (sqlToken, error) {
+	start := t.index
+	name, ok := t.quoted(closing, closing != ']')
+	if !ok {
+		return sqlToken{}, t.syntaxError(start, "unrecognized token")
+	}
+
+	return sqlToken{tokenType: identifierToken, value: name, quoted: true}, nil
+}
+
+func (t *tokenizer) tokenizer() ([]sqlToken, error) {
+	tokens := []sqlToken{}
+	for {
+		t.skipWhiteSpaces()
+		if t.eof() {
+			break
+		}
+
+		start := t.index
+		var token sqlToken
+		var err error
+
+		switch t.peek() {
+		case '(':
+			token = sqlToken{tokenType: lParenToken}
+			t.next()
+		case ')':
+			token = sqlToken{tokenType: rParenToken}
+			t.next()
+		case '*':
+			token = sqlToken{tokenType: starToken}
+			t.next()
+		case ',':
+			token = sqlToken{tokenType: commaToken}
+			t.next()
+		case ';':
+			token = sqlToken{tokenType: semicolonToken}
+			t.next()
+		case '>', '<':
+			op := string(t.peek())
+			next := t.next()
+			if next == '=' || (op == "<" && next == '>') {
+				op += string(next)
+				t.next()
+			}
+			// <> is the same operator as !=
+			if op == "<>" {
+				op = "!="
+			}
+			token = sqlToken{tokenType: opToken, value: op}
+		case '=':
+			// == is the same operator as =
+			if t.next() == '=' {
+				t.next()
+			}
+			token = sqlToken{tokenType: opToken, value: "="}
+		case '!':
+			if t.next() != '=' {
+				return nil, t.syntaxError(start, "unrecognized token")
+			}
+			t.next()
+			token = sqlToken{tokenType: opToken, value: "!="}
+		case '|':
+			if t.next() != '|' {
+				return nil, t.syntaxError(start, "unrecognized token")
+			}
+			t.next()
+			token = sqlToken{tokenType: opToken, value: "||"}
+		case '+', '-', '/', '%':
+			token = sqlToken{tokenType: opToken, value: string(t.peek())}
+			t.next()
+		case '"', '`':
+			token, err = t.quotedIdentifierParse(t.peek())
+		case '[':
+			token, err = t.quotedIdentifierParse(']')
+		case '\'':
+			token, err = t.singleQuoteParse()
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			token, err = t.numberParse()
+		case '.':
+			if t.index+1 < len(t.input) && isDigit(t.input[t.index+1]) {
+				token, err = t.numberParse()
+			} else {
+				token = sqlToken{tokenType: dotToken, value: "."}
+				t.next()
+			}
+		case 'x', 'X':
+			if t.index+1 < len(t.input) && t.input[t.index+1] == '\'' {
+				token, err = t.blobParse()
+			} else {
+				token, err = t.parseChars()
+			}
+		default:
+			token, err = t.parseChars()
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		token.position = start
+		token.raw = t.input[start:t.index]
+		tokens = append(tokens, token)
+	}
+	tokens = append(tokens, sqlToken{tokenType: eofToken, position: len(t.input)})
+	return tokens, nil
+}
+
+// Grammar
+// sqlStatement        -> (selectStatement | createStatement) ";"?
+
+// selectStatement     -> WithClause selectCore (compoundOperator selectCore)* OrderByClause LimitClause
+// WithClause          -> WITH RECURSIVE? cte ("," cte)* | ε
+// cte                 -> tableName ("(" columnName ("," columnName)* ")")? AS "(" selectStatement ")"
+// selectCore          -> SELECT (DISTINCT | ALL)? resultColumns FromClause WhereClause GroupByClause
+// compoundOperator    -> UNION ALL? | INTERSECT | EXCEPT
+// resultColumns       -> resultColumn ("," resultColumn)*
+// resultColumn        -> "*" | tableName "." "*" | expr alias?
+// alias               -> AS? (identifier | string)
+// FromClause          -> FROM tableRef (joinOperator tableRef joinConstraint)* | ε
+// tableRef            -> (tableName | "(" selectStatement ")") alias?
+// joinOperator        -> "," | JOIN | INNER JOIN | CROSS JOIN | LEFT OUTER? JOIN
+// joinConstraint      -> ON expr | USING "(" columnName ("," columnName)* ")" | ε
+// WhereClause         -> WHERE expr | ε
+// GroupByClause       -> GROUP BY expr ("," expr)* (HAVING expr)? | HAVING expr | ε
+// OrderByClause       -> ORDER BY orderingTerm ("," orderingTerm)* | ε
+// orderingTerm        -> expr (ASC | DESC)? (NULLS (FIRST | LAST))?
+// LimitClause         -> LIMIT expr ((OFFSET | ",") expr)? | ε
+
+// expr                -> orExpr
+// orExpr              -> andExpr (OR andExpr)*
+// andExpr             -> notExpr (AND notExpr)*
+// notExpr             -> NOT notExpr | equalityExpr
+// equalityExpr        -> comparisonExpr (("=" | "!=" | IS NOT?) comparisonExpr | NOT? BETWEEN comparisonExpr AND comparisonExpr | NOT? IN inList)*
+// inList              -> "(" (selectStatement | expr ("," expr)* | ε) ")"
+// comparisonExpr      -> additiveExpr (("<" | "<=" | ">" | ">=") additiveExpr)*
+// additiveExpr        -> multiplicativeExpr (("+" | "-") multiplicativeExpr)*
+// multiplicativeExpr  -> concatExpr (("*" | "/" | "%") concatExpr)*
+// concatExpr          -> unaryExpr ("||" unaryExpr)*
+// unaryExpr           -> ("-" | "+") unaryExpr | collateExpr
+// collateExpr         -> primaryExpr (COLLATE identifier)*
+// primaryExpr         -> literal | number | blob | NULL | "(" expr ")" | "(" selectStatement ")" | EXISTS "(" selectStatement ")"
+//                        | functionCall | (tableName ".")? columnName
+// number              -> digit+ ("." digit*)? exponent? | "." digit+ exponent? | "0x" hexDigit+
+// exponent            -> ("e" | "E") ("+" | "-")? digit+
+// functionCall        -> identifier "(" ("*" | (DISTINCT | ALL)? expr ("," expr)* | ε) ")"
+
+// createStatement     -> CREATE TABLE ifNotExistsOpt identifier "(" columnDef ("," columnDef)* ("," tableConstraint)* ")" tableOptions
+//                      | CREATE uniqueOpt INDEX ifNotExistsOpt identifier ON identifier "(" indexedColumnList ")" (WHERE expr)?
+// columnDef           -> identifier typeOpt columnConstraint*
+// typeOpt             -> identifier+ ("(" number ("," number)? ")")? | ε
+// columnConstraint    -> PRIMARY KEY | NOT NULL | AUTOINCREMENT | COLLATE identifier | other constraints which are skipped
+// tableConstraint     -> PRIMARY KEY "(" identifier ")" | other constraints which are skipped
+// tableOptions        -> WITHOUT ROWID | STRICT | ε
+
+// uniqueOpt           -> UNIQUE | ε
+// ifNotExistsOpt      -> IF NOT EXISTS | ε
+// indexedColumnList   -> indexedColumn ("," indexedColumn)*
+// indexedColumn       -> expr (COLLATE identifier)? (ASC | DESC)?
+
+type parser struct {
+	input  string
+	tokens []sqlToken
+	index  int
+}
+
+func (p *parser) peek() sqlToken {
+	return p.tokens[p.index]
+}
+
+// peekAt returns token following the current one by offset, eof token is returned past the end
+func (p *parser) peekAt(offset int) sqlToken {
+	if p.index+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.index+offset]
+}
+
+func (p *parser) next() sqlToken {
+	if p.peek().tokenType != eofToken {
+		p.index++
+	}
+
+	return p.peek()
+}
+
+// accept moves to the next token when the current one has expected type
+func (p *parser) accept(t tokenType) bool {
+	if p.peek().tokenType != t {
+		return false
+	}
+	p.next()
+	return true
+}
+
+func (p *parser) expect(t tokenType) (sqlToken, error) {
+	tok := p.peek()
+
+	if tok.tokenType != t {
+		return tok, p.syntaxError("expected token: %v, got: %v", t, tok.tokenType)
+	}
+	p.next()
+
+	return tok, nil
+}
+
+// isKeyword checks if the current token is the keyword, keywords which aren't reserved are tokenized as identifiers
+func (p *parser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token.tokenType == identifierToken && !token.quoted && strings.ToUpper(token.value) == keyword
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if !p.isKeyword(keyword) {
+		return false
+	}
+	p.next()
+	return true
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.syntaxError("expected %v", keyword)
+	}
+	return nil
+}
+
+// syntaxError reports error at the current token
+func (p *parser) syntaxError(format string, args ...any) error {
+	token := p.peek()
+	return &SyntaxError{Position: token.position, Near: token.raw, Message: fmt.Sprintf(format, args...)}
+}
+
+// textFrom returns query text from the start token up to the last consumed token
+func (p *parser) textFrom(start sqlToken) string {
+	if p.index == 0 {
+		return ""
+	}
+	last := p.tokens[p.index-1]
+	return p.input[start.position : last.position+len(last.raw)]
+}
+
+// name reads name of table, column or index, quoted names are accepted as well
+func (p *parser) name(what string) (string, error) {
+	token := p.peek()
+	if token.tokenType != identifierToken && token.tokenType != literalToken {
+		return "", p.syntaxError("expected %v", what)
+	}
+	p.next()
+
+	return token.value, nil
+}
+
+func parseSqlStatement(input string) (astNode, error) {
+	t := tokenizer{
+		input: input,
+	}
+	tokens, err := t.tokenizer()
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{
+		input:  input,
+		tokens: tokens,
+	}
+
+	var node astNode
+	switch {
+	case p.isSelect():
+		node, err = p.selectCause()
+	case p.peek().tokenType == createToken:
+		node, err = p.createCause()
+	default:
+		return nil, p.syntaxError("unknown statement type")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.accept(semicolonToken)
+	if p.peek().tokenType != eofToken {
+		return nil, p.syntaxError("unexpected token after end of statement")
+	}
+
+	return node, nil
+
+}
+
+type astNode interface{}
+
+type selectStatement struct {
+	// distinct is set by SELECT DISTINCT, duplicate result rows are then discarded
+	distinct bool
+	columns  []resultColumn
+	// from is tableRef or joinClause when tables are joined
+	from fromItem
+	// where is nil when there is no where clause
+	where expression
+	// groupBy is empty and having is nil when query doesn't group rows
+	groupBy []expression
+	having  expression
+	orderBy []orderingTerm
+	// limit and offset are nil when not specified
+	limit  expression
+	offset expression
+	// with holds common table expressions the statement can use as tables
+	with []commonTableExpr
+	// compound holds selects combined with this one, ORDER BY and LIMIT of the statement then apply
+	// to the combined rows
+	compound []compoundSelect
+}
+
+// compoundSelect is select combined with rows of the selects before it
+type compoundSelect struct {
+	// operator is UNION, UNION ALL, INTERSECT or EXCEPT
+	operator  string
+	statement selectStatement
+}
+
+// commonTableExpr is named select, columns are empty when they take names of the select columns
+type commonTableExpr struct {
+	name      string
+	columns   []string
+	statement selectStatement
+}
+
+// orderingTerm is one expression of ORDER BY clause
+type orderingTerm struct {
+	expr expression
+	desc bool
+	// nulls is FIRST or LAST, empty when not specified, by default nulls are the smallest values
+	nulls string
+}
+
+// resultColumn is one item of select list, star selects all columns of the table
+type resultColumn struct {
+	expr expression
+	// name is the expression text as written in the query
+	name string
+	// alias is the name given by AS, empty when not specified
+	alias string
+	star  bool
+	// table is set for table.*, star then selects only columns of that table
+	table string
+}
+
+// fromItem is tableRef or joinClause
+type fromItem interface{}
+
+// tableRef is table of FROM clause, subquery is set for derived table and name is then empty
+type tableRef struct {
+	name string
+	// alias is the name table is referenced by in the query, empty when table name is used
+	alias    string
+	subquery *selectStatement
+}
+
+// joinClause joins table to rows of the left side, joins of more tables are nested to the left
+type joinClause struct {
+	left  fromItem
+	right tableRef
+	// kind is INNER, LEFT or CROSS, comma join is INNER join
+	kind string
+	// on is nil when join has no ON condition
+	on expression
+	// using holds column names from USING, they must be present in both sides
+	using []string
+}
+
+// expression is node of expression tree used in select list and where clause
+type expression interface{}
+
+type columnRefExpr struct {
+	// table is empty when column name isn't qualified
+	table string
+	name  string
+}
+
+// literalExpr holds constant value in the same representation as values read from records
+type literalExpr struct {
+	value any
+}
+
+type unaryExpr struct {
+	// operator is one of -, +, NOT
+	operator string
+	operand  expression
+}
+
+type binaryExpr struct {
+	// operator is one of OR, AND, =, !=, <, <=, >, >=, +, -, *, /, %, ||
+	operator string
+	left     expression
+	right    expression
+	// encoding is text encoding of the database set by planner, BINARY collation compares text in it
+	encoding uint32
+}
+
+type betweenExpr struct {
+	expr     expression
+	low      expression
+	high     expression
+	not      bool
+	encoding uint32
+}
+
+// collateExpr sets collation used when expression is compared or sorted
+type collateExpr struct {
+	expr expression
+	// collation is the name as written, collation names are case insensitive
+	collation string
+}
+
+// subquerySyntax is SELECT in parentheses, its value is the first column of the first row
+type subquerySyntax struct {
+	statement selectStatement
+}
+
+// existsExpr is true when subquery returns any row
+type existsExpr struct {
+	subquery expression
+}
+
+// inExpr checks if value is one of list values or of values returned by subquery
+type inExpr struct {
+	expr expression
+	not  bool
+	// list is empty when subquery is set
+	list     []expression
+	subquery expression
+}
+
+type functionCallExpr struct {
+	// name is lower cased, function names are case insensitive
+	name string
+	args []expression
+	// star is set for count(*)
+	star bool
+	// distinct is set when aggregate uses only distinct values of its argument
+	distinct bool
+}
+
+type createTableStatement struct {
+	tableName    string
+	columns      []createTableColumn
+	withoutRowid bool
+}
+
+type constrain string
+
+const (
+	notNull       constrain = "NotNull"
+	primaryKey    constrain = "PrimaryKey"
+	autoIncrement constrain = "AutoIncrement"
+)
+
+type createTableColumn struct {
+	name       string
+	columnType string
+	constrains []constrain
+	// collation is upper cased name from COLLATE constraint, empty means BINARY
+	collation string
+}
+
+// isRowidAlias checks if column is INTEGER PRIMARY KEY, such column is not stored in record,
+// its value is the rowid of the row
+func (c createTableColumn) isRowidAlias() bool {
+	return strings.ToUpper(c.columnType) == "INTEGER" && slices.Contains(c.constrains, primaryKey)
+}
+
+type createIndexStatement struct {
+	indexName string
+	tableName string
+	unique    bool
+	columns   []indexedColumn
+	// where is condition of partial index, nil when index covers all rows
+	where expression
+}
+
+type indexedColumn struct {
+	// name is empty when index is built on expression
+	name      string
+	desc      bool
+	collation string
+}
+
+// isSelect checks if SELECT statement starts at the current token
+func (p *parser) isSelect() bool {
+	return p.peek().tokenType == selectToken || p.isKeyword("WITH")
+}
+
+func (p *parser) selectCause() (selectStatement, error) {
+	with, err := p.withClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	statement, err := p.selectCore()
+	if err != nil {
+		return selectStatement{}, err
+	}
+	statement.with = with
+
+	for {
+		operator, ok := p.compoundOperator()
+		if !ok {
+			break
+		}
+		core, err := p.selectCore()
+		if err != nil {
+			return selectStatement{}, err
+		}
+		statement.compound = append(statement.compound, compoundSelect{operator: operator, statement: core})
+	}
+
+	statement.orderBy, err = p.orderByClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	statement.limit, statement.offset, err = p.limitClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	return statement, nil
+}
+
+// withClause reads common table expressions, RECURSIVE is optional as common table expression
+// is recursive whenever it references itself
+func (p *parser) withClause() ([]commonTableExpr, error) {
+	if !p.acceptKeyword("WITH") {
+		return nil, nil
+	}
+	p.acceptKeyword("RECURSIVE")
+
+	ctes := []commonTableExpr{}
+	for {
+		var cte commonTableExpr
+		var err error
+		cte.name, err = p.name("table name")
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek().tokenType == lParenToken {
+			cte.columns, err = p.columnNames()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = p.expectKeyword("AS")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(lParenToken)
+		if err != nil {
+			return nil, err
+		}
+		cte.statement, err = p.subquery()
+		if err != nil {
+			return nil, err
+		}
+		ctes = append(ctes, cte)
+
+		if !p.accept(commaToken) {
+			return ctes, nil
+		}
+	}
+}
+
+// selectCore reads single SELECT without ORDER BY and LIMIT which belong to the whole compound select
+func (p *parser) selectCore() (selectStatement, error) {
+	_, err := p.expect(selectToken)
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	distinct := p.acceptKeyword("DISTINCT")
+	if !distinct {
+		p.acceptKeyword("ALL")
+	}
+
+	columns, err := p.resultColumns()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	from, err := p.fromClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	where, err := p.whereClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	groupBy, having, err := p.groupByClause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	return selectStatement{
+		distinct: distinct,
+		columns:  columns,
+		from:     from,
+		where:    where,
+		groupBy:  groupBy,
+		having:   having,
+	}, nil
+}
+
+// compoundOperator reads operator combining the following select, false is returned when there is none
+func (p *parser) compoundOperator() (string, bool) {
+	switch {
+	case p.acceptKeyword("UNION"):
+		if p.acceptKeyword("ALL") {
+			return "UNION ALL", true
+		}
+		return "UNION", true
+	case p.acceptKeyword("INTERSECT"):
+		return "INTERSECT", true
+	case p.acceptKeyword("EXCEPT"):
+		return "EXCEPT", true
+	default:
+		return "", false
+	}
+}
+
+func (p *parser) resultColumns() ([]resultColumn, error) {
+	columns := []resultColumn{}
+	for {
+		if p.accept(starToken) {
+			columns = append(columns, resultColumn{name: "*", star: true})
+		} else if p.peek().tokenType == identifierToken && p.peekAt(1).tokenType == dotToken && p.peekAt(2).tokenType == starToken {
+			start := p.peek()
+			p.index += 3
+			columns = append(columns, resultColumn{name: p.textFrom(start), star: true, table: start.value})
+		} else {
+			start := p.peek()
+			expr, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			name := p.textFrom(start)
+
+			alias, err := p.alias()
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, resultColumn{expr: expr, name: name, alias: alias})
+		}
+
+		if !p.accept(commaToken) {
+			return columns, nil
+		}
+	}
+}
+
+func (p *parser) fromClause() (fromItem, error) {
+	if !p.accept(fromToken) {
+		return nil, nil
+	}
+
+	table, err := p.tableRef()
+	if err != nil {
+		return nil, err
+	}
+
+	var from fromItem = table
+	for {
+		kind, ok, err := p.joinOperator()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return from, nil
+		}
+
+		join := joinClause{left: from, kind: kind}
+		join.right, err = p.tableRef()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.accept(onToken) {
+			join.on, err = p.expression()
+		} else if p.acceptKeyword("USING") {
+			join.using, err = p.columnNames()
+		}
+		if err != nil {
+			return nil, err
+		}
+		from = join
+	}
+}
+
+func (p *parser) tableRef() (tableRef, error) {
+	var table tableRef
+	var err error
+	if p.accept(lParenToken) {
+		statement, err := p.subquery()
+		if err != nil {
+			return tableRef{}, err
+		}
+		table.subquery = &statement
+	} else {
+		table.name, err = p.name("table name")
+		if err != nil {
+			return tableRef{}, err
+		}
+	}
+
+	table.alias, err = p.alias()
+	return table, err
+}
+
+// subquery reads SELECT statement following opening parenthesis up to the closing one
+func (p *parser) subquery() (selectStatement, error) {
+	if !p.isSelect() {
+		return selectStatement{}, p.syntaxError("expected SELECT")
+	}
+
+	statement, err := p.selectCause()
+	if err != nil {
+		return selectStatement{}, err
+	}
+
+	_, err = p.expect(rParenToken)
+	return statement, err
+}
+
+// joinOperator reads operator joining the following table, false is returned when there is no join
+func (p *parser) joinOperator() (kind string, ok bool, err error) {
+	switch {
+	case p.accept(commaToken):
+		return "INNER", true, nil
+	case p.acceptKeyword("JOIN"):
+		return "INNER", true, nil
+	case p.acceptKeyword("INNER"):
+		kind = "INNER"
+	case p.acceptKeyword("CROSS"):
+		kind = "CROSS"
+	case p.acceptKeyword("LEFT"):
+		kind = "LEFT"
+		p.acceptKeyword("OUTER")
+	case p.isKeyword("RIGHT"), p.isKeyword("FULL"), p.isKeyword("NATURAL"):
+		return "", false, unsupportedError("%v JOIN", strings.ToUpper(p.peek().value))
+	default:
+		return "", false, nil
+	}
+
+	return kind, true, p.expectKeyword("JOIN")
+}
+
+// columnNames reads comma separated names in parentheses
+func (p *parser) columnNames() ([]string, error) {
+	_, err := p.expect(lParenToken)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := []string{}
+	for {
+		column, err := p.name("column name")
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+
+		if !p.accept(commaToken) {
+			_, err = p.expect(rParenToken)
+			return columns, err
+		}
+	}
+}
+
+// keywords which can follow result column or table, they are not taken as alias without AS
+var clauseKeywordsAfterAlias = []string{"GROUP", "HAVING", "ORDER", "LIMIT", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "NATURAL", "USING", "UNION", "INTERSECT", "EXCEPT"}
+
+// alias reads name given by AS, AS can be omitted, empty name is returned when there is no alias
+func (p *parser) alias() (string, error) {
+	if p.acceptKeyword("AS") {
+		return p.name("alias")
+	}
+
+	token := p.peek()
+	if token.tokenType == literalToken || token.tokenType == identifierToken {
+		for _, keyword := range clauseKeywordsAfterAlias {
+			if p.isKeyword(keyword) {
+				return "", nil
+			}
+		}
+		return p.name("alias")
+	}
+	return "", nil
+}
+
+func (p *parser) whereClause() (expression, error) {
+	if !p.accept(whereToken) {
+		return nil, nil
+	}
+
+	return p.expression()
+}
+
+// groupByClause reads GROUP BY terms followed by optional HAVING, HAVING without GROUP BY makes single group
+func (p *parser) groupByClause() (groupBy []expression, having expression, err error) {
+	if p.acceptKeyword("GROUP") {
+		err = p.expectKeyword("BY")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for {
+			expr, err := p.expression()
+			if err != nil {
+				return nil, nil, err
+			}
+			groupBy = append(groupBy, expr)
+
+			if !p.accept(commaToken) {
+				break
+			}
+		}
+	}
+
+	if p.acceptKeyword("HAVING") {
+		having, err = p.expression()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return groupBy, having, nil
+}
+
+func (p *parser) orderByClause() ([]orderingTerm, error) {
+	if !p.acceptKeyword("ORDER") {
+		return nil, nil
+	}
+	err := p.expectKeyword("BY")
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []orderingTerm{}
+	for {
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		term := orderingTerm{expr: expr}
+
+		if p.acceptKeyword("DESC") {
+			term.desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+
+		if p.acceptKeyword("NULLS") {
+			if p.acceptKeyword("FIRST") {
+				term.nulls = "FIRST"
+			} else if p.acceptKeyword("LAST") {
+				term.nulls = "LAST"
+			} else {
+				return nil, p.syntaxError("expected FIRST or LAST")
+			}
+		}
+		terms = append(terms, term)
+
+		if !p.accept(commaToken) {
+			return terms, nil
+		}
+	}
+}
+
+// limitClause reads LIMIT count OFFSET skip, in the form LIMIT skip, count the offset goes first
+func (p *parser) limitClause() (limit expression, offset expression, err error) {
+	if !p.acceptKeyword("LIMIT") {
+		return nil, nil, nil
+	}
+
+	limit, err = p.expression()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.acceptKeyword("OFFSET") {
+		offset, err = p.expression()
+	} else if p.accept(commaToken) {
+		offset = limit
+		limit, err = p.expression()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return limit, offset, nil
+}
+
+func (p *parser) expression() (expression, error) {
+	return p.orExpression()
+}
+
+func (p *parser) orExpression() (expression, error) {
+	left, err := p.andExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(logicalOperatorOrToken) {
+		right, err := p.andExpression()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{operator: "OR", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) andExpression() (expression, error) {
+	left, err := p.notExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept(logicalOperatorAndToken) {
+		right, err := p.notExpression()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{operator: "AND", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) notExpression() (expression, error) {
+	if !p.accept(notToken) {
+		return p.equalityExpression()
+	}
+
+	operand, err := p.notExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryExpr{operator: "NOT", operand: operand}, nil
+}
+
+func (p *parser) equalityExpression() (expression, error) {
+	left, err := p.binaryExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		// NOT is part of this level only when followed by BETWEEN or IN
+		next := p.peekAt(1)
+		isIn := next.tokenType == identifierToken && !next.quoted && strings.ToUpper(next.value) == "IN"
+		not := p.peek().tokenType == notToken && (next.tokenType == betweenToken || isIn)
+		if not {
+			p.next()
+		}
+
+		if p.accept(betweenToken) {
+			left, err = p.betweenExpression(left, not)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.acceptKeyword("IN") {
+			left, err = p.inExpression(left, not)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		operator, ok := p.binaryOperator([]string{"=", "!="})
+		if !ok && p.acceptKeyword("IS") {
+			operator, ok = "IS", true
+			if p.accept(notToken) {
+				operator = "IS NOT"
+			}
+		}
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.binaryExpression(0)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{operator: operator, left: left, right: right}
+	}
+}
+
+// inExpression reads list of values or subquery in parentheses, the list can be empty
+func (p *parser) inExpression(expr expression, not bool) (expression, error) {
+	_, err := p.expect(lParenToken)
+	if err != nil {
+		return nil, err
+	}
+
+	in := inExpr{expr: expr, not: not, list: []expression{}}
+	if p.isSelect() {
+		statement, err := p.subquery()
+		if err != nil {
+			return nil, err
+		}
+		in.subquery = subquerySyntax{statement: statement}
+		in.list = nil
+		return in, nil
+	}
+
+	for !p.accept(rParenToken) {
+		if len(in.list) > 0 {
+			_, err = p.expect(commaToken)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		in.list = append(in.list, value)
+	}
+
+	return in, nil
+}
+
+// betweenExpression parses bounds, AND between them is not logical operator so bounds can't contain AND or OR
+func (p *parser) betweenExpression(expr expression, not bool) (expression, error) {
+	low, err := p.binaryExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.accept(logicalOperatorAndToken) {
+		return nil, p.syntaxError("expected AND in between condition")
+	}
+
+	high, err := p.binaryExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return betweenExpr{expr: expr, low: low, high: high, not: not}, nil
+}
+
+// binary operators with precedence higher than equality, from the lowest to the highest
+var binaryOperatorLevels = [][]string{
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+	{"||"},
+}
+
+func (p *parser) binaryExpression(level int) (expression, error) {
+	if level == len(binaryOperatorLevels) {
+		return p.unaryExpression()
+	}
+
+	left, err := p.binaryExpression(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.binaryOperator(binaryOperatorLevels[level])
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.binaryExpression(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{operator: operator, left: left, right: right}
+	}
+}
+
+// binaryOperator consumes current token when it is one of the operators, star token is multiplication here
+func (p *parser) binaryOperator(operators []string) (string, bool) {
+	token := p.peek()
+
+	operator := token.value
+	if token.tokenType == starToken {
+		operator = "*"
+	} else if token.tokenType != opToken {
+		return "", false
+	}
+
+	if !slices.Contains(operators, operator) {
+		return "", false
+	}
+	p.next()
+
+	return operator, true
+}
+
+func (p *parser) unaryExpression() (expression, error) {
+	token := p.peek()
+	if token.tokenType != opToken || (token.value != "-" && token.value != "+") {
+		return p.collateExpression()
+	}
+	p.next()
+
+	// smallest integer can be written only as negated literal, its absolute value doesn't fit in 64 bits
+	if next := p.peek(); token.value == "-" && next.tokenType == numberToken && next.value == "9223372036854775808" {
+		p.next()
+		return literalExpr{value: int64(math.MinInt64)}, nil
+	}
+
+	operand, err := p.unaryExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryExpr{operator: token.value, operand: operand}, nil
+}
+
+// collateExpression reads postfix COLLATE operator, it binds tighter than any other operator
+func (p *parser) collateExpression() (expression, error) {
+	expr, err := p.primaryExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.acceptKeyword("COLLATE") {
+		collation, err := p.name("collation name")
+		if err != nil {
+			return nil, err
+		}
+		expr = collateExpr{expr: expr, collation: collation}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) primaryExpression() (expression, error) {
+	token := p.peek()
+
+	switch token.tokenType {
+	case literalToken:
+		p.next()
+		return literalExpr{value: token.value}, nil
+	case blobToken:
+		p.next()
+		return literalExpr{value: []byte(token.value)}, nil
+	case numberToken:
+		p.next()
+		return literalExpr{value: parseNumber(token.value)}, nil
+	case lParenToken:
+		p.next()
+		if p.isSelect() {
+			statement, err := p.subquery()
+			return subquerySyntax{statement: statement}, err
+		}
+
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(rParenToken)
+		if err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case identifierToken:
+		if p.acceptKeyword("NULL") {
+			return literalExpr{value: nil}, nil
+		}
+		if p.isKeyword("EXISTS") && p.peekAt(1).tokenType == lParenToken {
+			p.index += 2
+			statement, err := p.subquery()
+			return existsExpr{subquery: subquerySyntax{statement: statement}}, err
+		}
+		p.next()
+		if p.peek().tokenType == lParenToken {
+			return p.functionCall(token)
+		}
+		if p.accept(dotToken) {
+			column, err := p.expect(identifierToken)
+			if err != nil {
+				return nil, err
+			}
+			return columnRefExpr{table: token.value, name: column.value}, nil
+		}
+		return columnRefExpr{name: token.value}, nil
+	default:
+		return nil, p.syntaxError("expected expression")
+	}
+}
+
+// parseNumber converts numeric literal checked by tokenizer, integers which don't fit in 64 bits become real numbers
+func parseNumber(text string) any {
+	if len(text) > 2 && (text[1] == 'x' || text[1] == 'X') {
+		// hex literal is two's complement 64 bit integer, 0xFFFFFFFFFFFFFFFF is -1
+		val, _ := strconv.ParseUint(text[2:], 16, 64)
+		return int64(val)
+	}
+
+	if !strings.ContainsAny(text, ".eE") {
+		if val, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return val
+		}
+	}
+
+	// out of range reals become infinity the same way as in sqlite
+	val, _ := strconv.ParseFloat(text, 64)
+	return val
+}
+
+func (p *parser) functionCall(name sqlToken) (expression, error) {
+	p.next()
+	call := functionCallExpr{name: strings.ToLower(name.value), args: []expression{}}
+
+	if p.acceptKeyword("DISTINCT") {
+		call.distinct = true
+	} else {
+		p.acceptKeyword("ALL")
+	}
+
+	if !call.distinct && p.accept(starToken) {
+		call.star = true
+	} else if call.distinct || p.peek().tokenType != rParenToken {
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+
+			if !p.accept(commaToken) {
+				break
+			}
+		}
+	}
+
+	_, err := p.expect(rParenToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+func (p *parser) createCause() (astNode, error) {
+	token := p.next()
+
+	switch token.tokenType {
+	case tableToken:
+		p.next()
+		return p.createTableClause()
+	case indexToken:
+		p.next()
+		return p.createIndexClause(false)
+	case uniqueToken:
+		p.next()
+		_, err := p.expect(indexToken)
+		if err != nil {
+			return nil, err
+		}
+		return p.createIndexClause(true)
+	default:
+		return nil, p.syntaxError("unsupported create statement")
+	}
+
+}
+
+func (p *parser) ifNotExists() error {
+	if !p.acceptKeyword("IF") {
+		return nil
+	}
+	if !p.accept(notToken) {
+		return p.syntaxError("expected NOT in if not exists clause")
+	}
+	return p.expectKeyword("EXISTS")
+}
+
+func (p *parser) createTableClause() (createTableStatement, error) {
+	err := p.ifNotExists()
+	if err != nil {
+		return createTableStatement{}, err
+	}
+
+	tableName, err := p.name("table name")
+	if err != nil {
+		return createTableStatement{}, err
+	}
+
+	_, err = p.expect(lParenToken)
+	if err != nil {
+		return createTableStatement{}, err
+	}
+
+	statement := createTableStatement{tableName: tableName, columns: []createTableColumn{}}
+	for {
+		if p.isTableConstraint() {
+			err = p.tableConstraint(&statement)
+		} else {
+			var column createTableColumn
+			column, err = p.columnDefinition()
+			statement.columns = append(statement.columns, column)
+		}
+		if err != nil {
+			return createTableStatement{}, err
+		}
+
+		if !p.accept(commaToken) {
+			break
+		}
+	}
+
+	_, err = p.expect(rParenToken)
+	if err != nil {
+		return createTableStatement{}, err
+	}
+
+	for p.peek().tokenType != eofToken && p.peek().tokenType != semicolonToken {
+		switch {
+		case p.acceptKeyword("WITHOUT"):
+			err = p.expectKeyword("ROWID")
+			if err != nil {
+				return createTableStatement{}, err
+			}
+			statement.withoutRowid = true
+		case p.acceptKeyword("STRICT"), p.accept(commaToken):
+		default:
+			return createTableStatement{}, p.syntaxError("unexpected table option")
+		}
+	}
+
+	return statement, nil
+
+}
+
+// words which end column type and start column constraint
+var columnConstraintKeywords = []string{
+	"CONSTRAINT", "PRIMARY", "NULL", "CHECK", "DEFAULT", "COLLATE", "REFERENCES", "GENERATED", "AS", "AUTOINCREMENT",
+}
+
+func (p *parser) columnDefinition() (createTableColumn, error) {
+	name, err := p.name("column name")
+	if err != nil {
+		return createTableColumn{}, err
+	}
+	column := createTableColumn{name: name, constrains: []constrain{}}
+
+	// type is optional and can have more words and size, e.g. VARCHAR(255) or DOUBLE PRECISION
+	start := p.peek()
+	hasType := false
+	for p.peek().tokenType == identifierToken && !slices.Contains(columnConstraintKeywords, strings.ToUpper(p.peek().value)) {
+		p.next()
+		hasType = true
+	}
+	if hasType && p.peek().tokenType == lParenToken {
+		p.skipTokens()
+	}
+	if hasType {
+		column.columnType = p.textFrom(start)
+	}
+
+	for p.peek().tokenType != commaToken && p.peek().tokenType != rParenToken && p.peek().tokenType != eofToken {
+		switch {
+		case p.acceptKeyword("PRIMARY"):
+			err = p.expectKeyword("KEY")
+			if err != nil {
+				return createTableColumn{}, err
+			}
+			column.constrains = append(column.constrains, primaryKey)
+		case p.accept(notToken):
+			err = p.expectKeyword("NULL")
+			if err != nil {
+				return createTableColumn{}, err
+			}
+			column.constrains = append(column.constrains, notNull)
+		case p.acceptKeyword("AUTOINCREMENT"):
+			column.constrains = append(column.constrains, autoIncrement)
+		case p.acceptKeyword("COLLATE"):
+			collation, err := p.name("collation name")
+			if err != nil {
+				return createTableColumn{}, err
+			}
+			column.collation = strings.ToUpper(collation)
+		default:
+			// other constraints don't change how rows are read
+			p.skipTokens()
+		}
+	}
+
+	return column, nil
+}
+
+func (p *parser) isTableConstraint() bool {
+	if p.peek().tokenType == uniqueToken {
+		return true
+	}
+
+	for _, keyword := range []string{"CONSTRAINT", "PRIMARY", "CHECK", "FOREIGN"} {
+		if p.isKeyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// tableConstraint reads table level constraint, only primary key on single column is kept
+// because it makes INTEGER column alias of the rowid
+func (p *parser) tableConstraint(statement *createTableStatement) error {
+	if p.acceptKeyword("CONSTRAINT") {
+		_, err := p.name("constraint name")
+		if err != nil {
+			return err
+		}
+	}
+
+	if p.acceptKeyword("PRIMARY") {
+		err := p.expectKeyword("KEY")
+		if err != nil {
+			return err
+		}
+		if p.peek().tokenType != lParenToken {
+			return p.syntaxError("expected primary key columns")
+		}
+
+		start := p.index
+		p.skipTokens()
+		keyColumns := p.tokens[start+1 : p.index-1]
+		if len(keyColumns) == 1 {
+			for i, column := range statement.columns {
+				if strings.EqualFold(column.name, keyColumns[0].value) {
+					statement.columns[i].constrains = append(statement.columns[i].constrains, primaryKey)
+				}
+			}
+		}
+	}
+
+	for p.peek().tokenType != commaToken && p.peek().tokenType != rParenToken && p.peek().tokenType != eofToken {
+		p.skipTokens()
+	}
+
+	return nil
+}
+
+// skipTokens skips current token, when it opens parentheses everything up to matching closing one is skipped
+func (p *parser) skipTokens() {
+	depth := 0
+	for p.peek().tokenType != eofToken {
+		switch p.peek().tokenType {
+		case lParenToken:
+			depth++
+		case rParenToken:
+			depth--
+		}
+		p.next()
+
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+func (p *parser) createIndexClause(unique bool) (createIndexStatement, error) {
+	err := p.ifNotExists()
+	if err != nil {
+		return createIndexStatement{}, err
+	}
+
+	indexName, err := p.name("index name")
+	if err != nil {
+		return createIndexStatement{}, err
+	}
+
+	_, err = p.expect(onToken)
+	if err != nil {
+		return createIndexStatement{}, err
+	}
+
+	tableName, err := p.name("table name")
+	if err != nil {
+		return createIndexStatement{}, err
+	}
+
+	if p.peek().tokenType != lParenToken {
+		return createIndexStatement{}, p.syntaxError("expect indexed columns to start with left parentheses")
+	}
+	p.next()
+
+	columns := []indexedColumn{}
+	for {
+		expr, err := p.expression()
+		if err != nil {
+			return createIndexStatement{}, err
+		}
+
+		// COLLATE is read as part of the expression, it belongs to indexed column
+		indexedColumn := indexedColumn{}
+		if collate, ok := expr.(collateExpr); ok {
+			indexedColumn.collation = strings.ToUpper(collate.collation)
+			expr = collate.expr
+		}
+		if column, ok := expr.(columnRefExpr); ok {
+			indexedColumn.name = column.name
+		}
+
+		if p.acceptKeyword("DESC") {
+			indexedColumn.desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
+		columns = append(columns, indexedColumn)
+
+		if !p.accept(commaToken) {
+			break
+		}
+	}
+
+	_, err = p.expect(rParenToken)
+	if err != nil {
+		return createIndexStatement{}, p.syntaxError("expected comma or right parentheses")
+	}
+
+	statement := createIndexStatement{
+		indexName: indexName,
+		tableName: tableName,
+		unique:    unique,
+		columns:   columns,
+	}
+
+	if p.accept(whereToken) {
+		statement.where, err = p.expression()
+		if err != nil {
+			return createIndexStatement{}, err
+		}
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,291 @@
+package sqlite
+
+import "fmt"
+
+// subqueryExpr is bound subquery, it is executed when its value is needed. Result of subquery which doesn't
+// reference outer query is the same every time so it is computed only once.
+type subqueryExpr struct {
+	plan     executionPlan
+	planner  planner
+	executor executor
+	// correlations hold values of outer query the subquery depends on
+	correlations []*correlation
+
+	// first row cache, firstRow is nil when subquery returns no rows
+	firstCached bool
+	firstRow    []any
+	// value set cache of IN operator
+	set *valueSet
+}
+
+// correlation is value of outer query used by subquery, it is set before every execution of the subquery
+type correlation struct {
+	// expr is bound to row of the outer query
+	expr  expression
+	value any
+}
+
+// correlatedColumnExpr references value of outer query in correlated subquery
+type correlatedColumnExpr struct {
+	correlation *correlation
+	name        string
+}
+
+func (s *subqueryExpr) isCorrelated() bool {
+	return len(s.correlations) > 0
+}
+
+// bindSubquery plans subquery in scope of the query being bound, columns not found in the subquery
+// are looked up in the outer query
+func (b *columnBinder) bindSubquery(statement selectStatement) (*subqueryExpr, error) {
+	plan, correlations, err := b.planner.prepareSelect(statement, b, &cteScope{parent: b.scope, subquery: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return &subqueryExpr{
+		plan:         plan,
+		planner:      b.planner,
+		executor:     b.planner.executor,
+		correlations: correlations,
+	}, nil
+}
+
+// bindScalarSubquery binds subquery which value is used, it must return single column
+func (b *columnBinder) bindScalarSubquery(statement selectStatement) (*subqueryExpr, error) {
+	subquery, err := b.bindSubquery(statement)
+	if err != nil {
+		return nil, err
+	}
+	if columns := len(subquery.plan.columns); columns != 1 {
+		return nil, fmt.Errorf("sub-select returns %v columns - expected 1", columns)
+	}
+	return subquery, nil
+}
+
+// correlate returns reference to value of outer query, the same value is referenced by one correlation
+func (b *columnBinder) correlate(expr expression, name string) expression {
+	for _, c := range b.correlations {
+		if sameExpr(c.expr, expr) {
+			return correlatedColumnExpr{correlation: c, name: name}
+		}
+	}
+
+	c := &correlation{expr: expr}
+	b.correlations = append(b.correlations, c)
+	return correlatedColumnExpr{correlation: c, name: name}
+}
+
+// iterator executes subquery for row of the outer query, access paths of correlated subquery
+// are chosen again as index seek depends on values of the outer query
+func (s *subqueryExpr) iterator(row []any) (rowIterator, error) {
+	plan := s.plan
+	if s.isCorrelated() {
+		for _, c := range s.correlations {
+			val, err := evalExpr(c.expr, row)
+			if err != nil {
+				return nil, err
+			}
+			c.value = val
+		}
+
+		var err error
+		plan, err = s.planner.chooseAccessPaths(plan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.executor.iterator(plan)
+}
+
+// first returns the first row of subquery result, nil is returned when there are no rows
+func (s *subqueryExpr) first(row []any) ([]any, error) {
+	if s.firstCached {
+		return s.firstRow, nil
+	}
+
+	rows, err := s.iterator(row)
+	if err != nil {
+		return nil, err
+	}
+	first, _, err := rows.next()
+	closeErr := rows.close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.isCorrelated() {
+		s.firstCached, s.firstRow = true, first
+	}
+	return first, nil
+}
+
+// valueSet holds values compared by IN operator, values equal by the comparison have the same key
+type valueSet struct {
+	keys    map[string]bool
+	hasNull bool
+}
+
+func valueKey(val any, affinity affinity, collation string) string {
+	return string(encodeRecord([]any{equalityKey(applyAffinity(affinity, val), collation)}))
+}
+
+// values returns set of the first column values of all subquery rows
+func (s *subqueryExpr) values(row []any, affinity affinity, collation string) (*valueSet, error) {
+	if s.set != nil {
+		return s.set, nil
+	}
+
+	rows, err := s.iterator(row)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.close()
+
+	set := &valueSet{keys: map[string]bool{}}
+	for {
+		values, ok, err := rows.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		if values[0] == nil {
+			set.hasNull = true
+		} else {
+			set.keys[valueKey(values[0], affinity, collation)] = true
+		}
+	}
+
+	if !s.isCorrelated() {
+		s.set = set
+	}
+	return set, nil
+}
+
+// evalSubquery returns the first column of the first row, null when subquery returns no rows
+func evalSubquery(s *subqueryExpr, row []any) (any, error) {
+	first, err := s.first(row)
+	if err != nil || first == nil {
+		return nil, err
+	}
+	return first[0], nil
+}
+
+func evalExists(e existsExpr, row []any) (any, error) {
+	first, err := e.subquery.(*subqueryExpr).first(row)
+	if err != nil {
+		return nil, err
+	}
+	return boolValue(first != nil), nil
+}
+
+// evalIn compares value with every value of the list or subquery, result is null when value is not found
+// and some of the compared values are null. Value is never found in empty list, not even null.
+func evalIn(e inExpr, row []any) (any, error) {
+	left, err := evalExpr(e.expr, row)
+	if err != nil {
+		return nil, err
+	}
+
+	found, hasNull := false, false
+	if subquery, ok := e.subquery.(*subqueryExpr); ok {
+		column := subquery.plan.projection[0]
+		affinity := comparisonAffinity(exprAffinity(e.expr), exprAffinity(column))
+		collation := comparisonCollation(e.expr, column)
+		set, err := subquery.values(row, affinity, collation)
+		if err != nil {
+			return nil, err
+		}
+
+		isEmpty := len(set.keys) == 0 && !set.hasNull
+		if isEmpty {
+			return boolValue(e.not), nil
+		}
+		found = left != nil && set.keys[valueKey(left, affinity, collation)]
+		hasNull = set.hasNull
+	} else {
+		if len(e.list) == 0 {
+			return boolValue(e.not), nil
+		}
+
+		for _, expr := range e.list {
+			val, err := evalExpr(expr, row)
+			if err != nil {
+				return nil, err
+			}
+
+			affinity := comparisonAffinity(exprAffinity(e.expr), exprAffinity(expr))
+			// equality doesn't depend on text encoding
+			equal := compareOperator("=", applyAffinity(affinity, left), applyAffinity(affinity, val), comparisonCollation(e.expr, expr), utf8Encoding)
+			if equal == nil {
+				hasNull = true
+			} else if isTrue(equal) {
+				found = true
+				break
+			}
+		}
+	}
+
+	switch {
+	case left == nil, !found && hasNull:
+		return nil, nil
+	default:
+		return boolValue(found != e.not), nil
+	}
+}
+
+// derivedTableIterator returns rows of derived table or common table expression placed at the table position in joined row
+type derivedTableIterator struct {
+	source rowIterator
+	scan   tableScan
+	width  int
+}
+
+func (d *derivedTableIterator) next() ([]any, bool, error) {
+	for {
+		values, ok, err := d.source.next()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		// derived table has no rowid, it stays null
+		row := make([]any, d.width)
+		copy(row[d.scan.offset:], values)
+
+		ok, err = matchWhere(d.scan.filter, row)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			return row, true, nil
+		}
+	}
+}
+
+func (d *derivedTableIterator) close() error {
+	return d.source.close()
+}
+
+// derivedTable declares table of subquery result columns, columns have affinity and collation of their expressions
+func derivedTable(name string, plan executionPlan) createTableStatement {
+	table := createTableStatement{tableName: name}
+	for i, expr := range plan.projection {
+		collation, _ := exprCollation(expr)
+		if collation == "BINARY" {
+			collation = ""
+		}
+		table.columns = append(table.columns, createTableColumn{
+			name:       plan.columns[i],
+			columnType: string(exprAffinity(expr)),
+			collation:  collation,
+		})
+	}
+	return table
+}
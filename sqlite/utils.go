@@ -0,0 +1,81 @@
+package sqlite
+
+func parseVarint(buffer []byte) (uint64, []byte) {
+	currentOffset := 0
+	var varint uint64
+
+	// truncated varint stops at the end of the buffer instead of reading past it
+	for i := 0; i < 9 && currentOffset < len(buffer); i++ {
+		b := buffer[currentOffset]
+
+		// ninth byte uses all 8 bits
+		if i == 8 {
+			varint = (varint << 8) | uint64(b)
+			currentOffset++
+			break
+		}
+
+		varint <<= 7
+		varint |= uint64(b & 0b01111111)
+
+		currentOffset++
+
+		if b&0b10000000 == 0 {
+			break
+		}
+	}
+
+	return varint, buffer[currentOffset:]
+}
+
+// appendVarint appends value encoded as sqlite varint, it is the inverse of parseVarint
+func appendVarint(buffer []byte, val uint64) []byte {
+	var encoded [9]byte
+
+	// values using more than 56 bits take nine bytes, the last one holds full 8 bits
+	if val>>56 != 0 {
+		encoded[8] = byte(val)
+		val >>= 8
+		for i := 7; i >= 0; i-- {
+			encoded[i] = byte(val&0b01111111) | 0b10000000
+			val >>= 7
+		}
+		return append(buffer, encoded[:]...)
+	}
+
+	size := 0
+	for {
+		encoded[size] = byte(val & 0b01111111)
+		size++
+		val >>= 7
+		if val == 0 {
+			break
+		}
+	}
+
+	// groups were produced from the least significant one, all bytes except the last have high bit set
+	reverse(encoded[:size])
+	for i := 0; i < size-1; i++ {
+		encoded[i] |= 0b10000000
+	}
+
+	return append(buffer, encoded[:size]...)
+}
+
+// bigEndianSigned decodes big-endian two's-complement integer of 1 to 8 bytes
+func bigEndianSigned(data []byte) int64 {
+	var val uint64
+	for _, b := range data {
+		val = (val << 8) | uint64(b)
+	}
+
+	// move sign bit to the top and shift back to extend it
+	shift := 64 - 8*len(data)
+	return int64(val<<shift) >> shift
+}
+
+func reverse[T any](s []T) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
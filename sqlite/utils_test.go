@@ -0,0 +1,51 @@
+package sqlite
+
+import "testing"
+
+func TestVarint(t *testing.T) {
+	data := []byte{128, 1, 4}
+	val, rest := parseVarint(data)
+
+	if val != 1 {
+		t.Errorf("wrong val, got: %v", val)
+	}
+
+	if len(rest) != 1 && rest[0] != 4 {
+		t.Errorf("expected to return one byte '4', got: %v", rest[0])
+	}
+}
+
+func TestVarint2(t *testing.T) {
+	data := []byte{0x81, 0x47}
+	val, _ := parseVarint(data)
+	// val, _ := parseVarint2(data, 0)
+
+	if val != 199 {
+		t.Errorf("wrong val, got: %v", val)
+	}
+
+}
+
+func TestVarintNineBytes(t *testing.T) {
+	data := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	val, _ := parseVarint(data)
+
+	if int64(val) != -1 {
+		t.Errorf("expected nine byte varint to use all bits of last byte, got: %v", int64(val))
+	}
+}
+
+func TestAppendVarint(t *testing.T) {
+	for _, val := range []uint64{0, 1, 127, 128, 199, 16383, 16384, 1 << 56, 1<<56 - 1, 1<<64 - 1} {
+		encoded := appendVarint(nil, val)
+		decoded, rest := parseVarint(encoded)
+
+		if decoded != val || len(rest) != 0 {
+			t.Errorf("expected %v to be decoded back, got: %v with %v bytes left", val, decoded, len(rest))
+		}
+	}
+
+	if encoded := appendVarint(nil, 199); len(encoded) != 2 || encoded[0] != 0x81 || encoded[1] != 0x47 {
+		t.Errorf("expected 199 to be encoded as 0x81 0x47, got: %x", encoded)
+	}
+}
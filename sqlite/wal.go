@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	walHeaderSize      = 32
+	walFrameHeaderSize = 24
+	// magic number tells the byte order used by checksums, it is big-endian when the last bit is set
+	walMagicLittleEndian uint32 = 0x377f0682
+	walMagicBigEndian    uint32 = 0x377f0683
+)
+
+type walHeader struct {
+	magic          uint32
+	formatVersion  uint32
+	pageSize       uint32
+	checkpointSeq  uint32
+	salt1          uint32
+	salt2          uint32
+	checksum1      uint32
+	checksum2      uint32
+	checksumEndian binary.ByteOrder
+}
+
+type walFrameHeader struct {
+	pageNumber    uint32
+	dbSizeInPages uint32
+	salt1         uint32
+	salt2         uint32
+	checksum1     uint32
+	checksum2     uint32
+	isCommitFrame bool
+}
+
+// wal keeps location of the latest committed version of every page stored in write ahead log
+type wal struct {
+	walFile *os.File
+	header  walHeader
+	frames  map[int]int64
+	// database size in pages after the last commit
+	dbSizeInPages uint32
+}
+
+func parseWalHeader(data []byte) (walHeader, error) {
+	magic := binary.BigEndian.Uint32(data[0:4])
+
+	var checksumEndian binary.ByteOrder
+	switch magic {
+	case walMagicLittleEndian:
+		checksumEndian = binary.LittleEndian
+	case walMagicBigEndian:
+		checksumEndian = binary.BigEndian
+	default:
+		return walHeader{}, fmt.Errorf("wrong wal magic number: %x", magic)
+	}
+
+	return walHeader{
+		magic:          magic,
+		formatVersion:  binary.BigEndian.Uint32(data[4:8]),
+		pageSize:       binary.BigEndian.Uint32(data[8:12]),
+		checkpointSeq:  binary.BigEndian.Uint32(data[12:16]),
+		salt1:          binary.BigEndian.Uint32(data[16:20]),
+		salt2:          binary.BigEndian.Uint32(data[20:24]),
+		checksum1:      binary.BigEndian.Uint32(data[24:28]),
+		checksum2:      binary.BigEndian.Uint32(data[28:32]),
+		checksumEndian: checksumEndian,
+	}, nil
+}
+
+func parseWalFrameHeader(data []byte) walFrameHeader {
+	dbSizeInPages := binary.BigEndian.Uint32(data[4:8])
+
+	return walFrameHeader{
+		pageNumber:    binary.BigEndian.Uint32(data[0:4]),
+		dbSizeInPages: dbSizeInPages,
+		salt1:         binary.BigEndian.Uint32(data[8:12]),
+		salt2:         binary.BigEndian.Uint32(data[12:16]),
+		checksum1:     binary.BigEndian.Uint32(data[16:20]),
+		checksum2:     binary.BigEndian.Uint32(data[20:24]),
+		// only commit frame has database size after commit set
+		isCommitFrame: dbSizeInPages != 0,
+	}
+}
+
+// walChecksum continues checksum calculation from s1 and s2 over data, its length has to be multiple of 8
+func walChecksum(data []byte, byteOrder binary.ByteOrder, s1, s2 uint32) (uint32, uint32) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		s1 += byteOrder.Uint32(data[i:i+4]) + s2
+		s2 += byteOrder.Uint32(data[i+4:i+8]) + s1
+	}
+
+	return s1, s2
+}
+
+// readWal loads frame index of the wal file next to database, nil is returned when there is no wal file.
+// Frames are valid only when salts match header and checksum chain is not broken,
+// only frames up to the last valid commit frame are used, the rest belongs to not finished transaction.
+func readWal(databaseFilePath string, pageSize int) (*wal, error) {
+	walFile, err := os.Open(databaseFilePath + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wal := loadWalFrames(walFile, pageSize)
+	if wal == nil {
+		walFile.Close()
+	}
+
+	return wal, nil
+}
+
+func loadWalFrames(walFile *os.File, pageSize int) *wal {
+	headerData := make([]byte, walHeaderSize)
+	_, err := io.ReadFull(walFile, headerData)
+	if err != nil {
+		// wal file can be empty after checkpoint
+		return nil
+	}
+
+	header, err := parseWalHeader(headerData)
+	if err != nil {
+		return nil
+	}
+
+	s1, s2 := walChecksum(headerData[:24], header.checksumEndian, 0, 0)
+	if s1 != header.checksum1 || s2 != header.checksum2 || int(header.pageSize) != pageSize {
+		return nil
+	}
+
+	log := &wal{
+		walFile: walFile,
+		header:  header,
+		frames:  make(map[int]int64),
+	}
+
+	pendingFrames := make(map[int]int64)
+	frame := make([]byte, walFrameHeaderSize+pageSize)
+	offset := int64(walHeaderSize)
+	for {
+		_, err = io.ReadFull(walFile, frame)
+		if err != nil {
+			break
+		}
+
+		frameHeader := parseWalFrameHeader(frame[:walFrameHeaderSize])
+		if frameHeader.salt1 != header.salt1 || frameHeader.salt2 != header.salt2 {
+			break
+		}
+
+		s1, s2 = walChecksum(frame[:8], header.checksumEndian, s1, s2)
+		s1, s2 = walChecksum(frame[walFrameHeaderSize:], header.checksumEndian, s1, s2)
+		if s1 != frameHeader.checksum1 || s2 != frameHeader.checksum2 {
+			break
+		}
+
+		pendingFrames[int(frameHeader.pageNumber)] = offset + walFrameHeaderSize
+
+		if frameHeader.isCommitFrame {
+			for pageNumber, pageOffset := range pendingFrames {
+				log.frames[pageNumber] = pageOffset
+			}
+			clear(pendingFrames)
+			log.dbSizeInPages = frameHeader.dbSizeInPages
+		}
+
+		offset += int64(len(frame))
+	}
+
+	return log
+}
+
+// pageOffset returns offset of the latest committed version of the page in wal file
+func (w *wal) pageOffset(pageNumber int) (int64, bool) {
+	if w == nil {
+		return 0, false
+	}
+
+	offset, ok := w.frames[pageNumber]
+	return offset, ok
+}
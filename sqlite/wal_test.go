@@ -0,0 +1,125 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func buildWal(pageSize int, frames []walFrameHeader) []byte {
+	header := make([]byte, walHeaderSize)
+	binary.BigEndian.PutUint32(header[0:4], walMagicLittleEndian)
+	binary.BigEndian.PutUint32(header[4:8], 3007000)
+	binary.BigEndian.PutUint32(header[8:12], uint32(pageSize))
+	binary.BigEndian.PutUint32(header[16:20], 11)
+	binary.BigEndian.PutUint32(header[20:24], 22)
+	s1, s2 := walChecksum(header[:24], binary.LittleEndian, 0, 0)
+	binary.BigEndian.PutUint32(header[24:28], s1)
+	binary.BigEndian.PutUint32(header[28:32], s2)
+
+	wal := header
+	for _, frameHeader := range frames {
+		frame := make([]byte, walFrameHeaderSize+pageSize)
+		binary.BigEndian.PutUint32(frame[0:4], frameHeader.pageNumber)
+		binary.BigEndian.PutUint32(frame[4:8], frameHeader.dbSizeInPages)
+		binary.BigEndian.PutUint32(frame[8:12], frameHeader.salt1)
+		binary.BigEndian.PutUint32(frame[12:16], frameHeader.salt2)
+		frame[walFrameHeaderSize] = byte(frameHeader.pageNumber)
+
+		s1, s2 = walChecksum(frame[:8], binary.LittleEndian, s1, s2)
+		s1, s2 = walChecksum(frame[walFrameHeaderSize:], binary.LittleEndian, s1, s2)
+		binary.BigEndian.PutUint32(frame[16:20], s1)
+		binary.BigEndian.PutUint32(frame[20:24], s2)
+
+		wal = append(wal, frame...)
+	}
+
+	return wal
+}
+
+func TestReadWalUsesOnlyCommittedFrames(t *testing.T) {
+	databaseFilePath := filepath.Join(t.TempDir(), "test.db")
+
+	wal := buildWal(512, []walFrameHeader{
+		{pageNumber: 2, salt1: 11, salt2: 22},
+		{pageNumber: 3, dbSizeInPages: 3, salt1: 11, salt2: 22},
+		{pageNumber: 2, salt1: 11, salt2: 22},
+		{pageNumber: 4, dbSizeInPages: 4, salt1: 11, salt2: 22},
+		// transaction which was not committed yet
+		{pageNumber: 3, salt1: 11, salt2: 22},
+	})
+	err := os.WriteFile(databaseFilePath+"-wal", wal, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walIndex, err := readWal(databaseFilePath, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if walIndex == nil {
+		t.Fatalf("expected wal to be read")
+	}
+
+	frameSize := int64(walFrameHeaderSize + 512)
+	expectedOffsets := map[int]int64{
+		2: walHeaderSize + 2*frameSize + walFrameHeaderSize,
+		3: walHeaderSize + frameSize + walFrameHeaderSize,
+		4: walHeaderSize + 3*frameSize + walFrameHeaderSize,
+	}
+	for pageNumber, expectedOffset := range expectedOffsets {
+		offset, ok := walIndex.pageOffset(pageNumber)
+		if !ok || offset != expectedOffset {
+			t.Errorf("expected page %v to be at offset %v, got: %v", pageNumber, expectedOffset, offset)
+		}
+	}
+
+	if walIndex.dbSizeInPages != 4 {
+		t.Errorf("expected database size from the last commit to be 4, got: %v", walIndex.dbSizeInPages)
+	}
+}
+
+func TestReadWalStopsAtInvalidFrame(t *testing.T) {
+	databaseFilePath := filepath.Join(t.TempDir(), "test.db")
+
+	wal := buildWal(512, []walFrameHeader{
+		{pageNumber: 2, dbSizeInPages: 2, salt1: 11, salt2: 22},
+		// frame left from previous wal generation has different salt
+		{pageNumber: 3, dbSizeInPages: 3, salt1: 10, salt2: 22},
+	})
+	err := os.WriteFile(databaseFilePath+"-wal", wal, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walIndex, err := readWal(databaseFilePath, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := walIndex.pageOffset(2); !ok {
+		t.Errorf("expected page 2 to be read from wal")
+	}
+
+	if _, ok := walIndex.pageOffset(3); ok {
+		t.Errorf("expected frame with wrong salt to be ignored")
+	}
+
+	// corrupt page content of the first frame, checksum chain is broken from the start
+	wal[walHeaderSize+walFrameHeaderSize+100] ^= 0xff
+	err = os.WriteFile(databaseFilePath+"-wal", wal, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walIndex, err = readWal(databaseFilePath, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := walIndex.pageOffset(2); ok {
+		t.Errorf("expected frame with wrong checksum to be ignored")
+	}
+}